@@ -9,25 +9,67 @@ Usage:
 
 The commands are:
 
+        init        scan and download all dependence
         fetch       fetch a remote dependency
         restore     restore dependencies from manifest
         update      update a local dependency
         list        list dependencies one per line
         delete      delete a local dependency
+        diff        show local modifications of dependencies
+        patch       record local modifications of a dependency
+        replace     use a local directory for a dependency
+        outdated    report dependencies behind their upstream
+        prune       remove unused vendored packages
+        unused      list dependencies nothing imports
+        missing     list imports which are not vendored
+        graph       print the dependency graph
+        why         explain why a package is vendored
+        licenses    report the licenses of the dependencies
+        notices     generate a third party notices document
+        sbom        export a software bill of materials
+        audit       check dependencies against security advisories
+        import      import dependencies from another vendoring tool
+        export      export the manifest for another dependency manager
 
 Use "gvt help [command]" for more information about a command.
 
 
+Scan and download all dependence
+
+Usage:
+        gvt init
+
+sacn all source files and download all dependence
+
 Fetch a remote dependency
 
 Usage:
-        gvt fetch [-branch branch] [-revision rev | -tag tag] [-precaire] [-no-recurse] [-t|-a] importpath
+        gvt fetch [-branch branch] [-revision rev | -tag tag | -version constraint] [-repo url] [-tags tags] [-precaire] [-strict] [-no-recurse | -latest] [-t|-a] importpath
 
 fetch vendors an upstream import path.
 
 Recursive dependencies are fetched (at their master/tip/HEAD revision), unless they
 or their parent package are already present.
 
+Recursive dependencies pinned by a fetched dependency are fetched at the pinned
+version instead, unless -latest is given. Pins are read from the go.mod file of
+the module of the dependency, for the modules it requires, and from its
+vendor/manifest file or the lock file of another vendoring tool, as listed by
+"gvt help import". Replacements by other modules or forks are followed too.
+When several dependencies pin the same one, the highest version wins over the
+others and over plain revisions. Pins are ignored for packages of the
+repository of the fetched import path, which are taken at its revision.
+
+Import paths with a major version suffix, like example.com/mod/v2/pkg, are
+taken from the v2 folder of the repository if it holds the module, or else
+from its newest v2 tag or its v2 branch. The module of a dependency and its
+version are recorded in the manifest.
+
+Imports of the standard library, the packages of GOROOT/src, are not fetched;
+any other import is. An importRules file, in the current or in the home
+directory, can change that: each line holds an import path prefix followed by
+"fetch" or "ignore", like "corp/ ignore" for packages provided by other means.
+
 If a subpackage of a dependency being fetched is already present, it will be deleted.
 
 The import path may include a url scheme. This may be useful when fetching dependencies
@@ -43,13 +85,33 @@ Flags:
 		If not supplied the default upstream branch will be used.
 	-no-recurse
 		do not fetch recursively.
+	-latest
+		fetch recursive dependencies at their latest revision, ignoring the
+		versions pinned by the fetched dependencies.
 	-tag tag
-		fetch the specified tag.
+		fetch the specified tag. gvt update will move to newer tags.
+	-version constraint
+		fetch the newest tag matching the semantic version constraint, like
+		"^1.4" or "~1.2". Will also be used by gvt update.
+	-tags tags
+		only look for imports in the files built with the comma separated
+		build tags, like "linux,amd64,!appengine". GOOS and GOARCH values
+		are tags too; when none is given all of them are considered, but
+		the negated ones. The tags are recorded in the manifest and also
+		used by gvt update, prune, unused and missing.
 	-revision rev
 		fetch the specific revision from the branch or repository.
 		If no revision supplied, the latest available will be fetched.
+	-repo url
+		fetch from the given repository, for example a fork, instead of the one
+		of the import path. The import path is kept, and gvt update will follow
+		the repository. A url with a scheme is taken as the root of the
+		repository, anything else is resolved like an import path.
 	-precaire
 		allow the use of insecure protocols.
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
 
 Restore dependencies from manifest
 
@@ -76,23 +138,46 @@ Flags:
 Update a local dependency
 
 Usage:
-        gvt update [ -all | importpath ]
+        gvt update [ -all | [-branch branch] [-revision rev | -tag tag] importpath ] [-semver] [-dry-run] [-recurse] [-strict]
 
 update replaces the source with the latest available from the head of the fetched branch.
 
-Updating from one copy of a dependency to another is ONLY possible when the
-dependency was fetched by branch, without using -tag or -revision. It will be
-updated to the HEAD of that branch, switching branches is not supported.
+Dependencies fetched with -version are updated to the newest tag matching their
+version constraint, and dependencies fetched with -tag to the newest tag of the
+same major version, in semantic version order; use -tag to move to another
+major version. Dependencies whose tag is not a semantic version are left
+unchanged. Dependencies fetched with -revision are not updated,
+unless one of -branch, -tag or -revision is supplied.
 
-To update across branches, or from one tag/revision to another, you must first
-use delete to remove the dependency, then fetch [ -tag | -revision | -branch ]
-to replace it.
+-branch, -tag and -revision switch a dependency to another branch, tag or revision
+in place, keeping its other settings, like -t, -a, the repository and the patches.
+The version constraint is dropped.
+
+Dependencies replaced by a local directory are not updated.
 
 Flags:
 	-all
 		update all dependencies in the manifest.
+	-branch branch
+		switch to the head of the named branch. Will also be used by later updates.
+	-tag tag
+		switch to the specified tag.
+	-revision rev
+		switch to the specific revision, from the branch if one is supplied.
+	-semver
+		only update dependencies that have a version constraint.
+	-dry-run
+		print the revision each dependency would move to, the commits in between
+		and the files that would change, without changing anything. Licenses
+		refused by the license policy are reported instead of aborting.
+	-recurse
+		fetch the packages newly imported by the updated dependencies, like fetch
+		does, and report the dependencies that are not imported anymore.
 	-precaire
 		allow the use of insecure protocols.
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
 
 List dependencies one per line
 
@@ -101,10 +186,12 @@ Usage:
 
 list formats the contents of the manifest file.
 
+Dependencies replaced by a local directory are marked with the directory.
+
 Flags:
 	-f
 		controls the template used for printing each manifest entry. If not supplied
-		the default value is "{{.Importpath}}\t{{.Repository}}{{.Path}}\t{{.Branch}}\t{{.Revision}}"
+		the default value is "{{.Importpath}}\t{{.Repository}}{{.Path}}\t{{.Branch}}\t{{.Revision}}{{if .Replace}}\treplaced by {{.Replace}}{{end}}"
 
 Delete a local dependency
 
@@ -117,5 +204,353 @@ Flags:
 	-all
 		remove all dependencies
 
+Show local modifications of dependencies
+
+Usage:
+        gvt diff [-precaire] [importpath]
+
+diff prints the local modifications of vendored dependencies as a unified diff.
+
+The revision recorded in the manifest is checked out, the same files that fetch
+would copy are selected, and the result is compared against the vendor folder.
+If no import path is supplied, all dependencies are compared.
+
+Flags:
+	-precaire
+		allow the use of insecure protocols.
+
+Record local modifications of a dependency
+
+Usage:
+        gvt patch [-precaire] save | drop importpath
+
+patch manages the local patches of a dependency.
+
+Patches are kept in vendor/.patches/importpath/ and listed in the manifest.
+They are applied in order on top of the upstream source by fetch, update and
+restore, which fail if a patch does not apply cleanly anymore.
+
+Patches are not removed by delete, a later fetch of the same import path will
+pick them up again.
+
+Subcommands:
+	save importpath
+		record the local modifications of the dependency that are not covered
+		by its existing patches as a new patch.
+	drop importpath
+		remove all the patches of the dependency. The vendor folder is left untouched.
+
+Flags:
+	-precaire
+		allow the use of insecure protocols.
+
+Use a local directory for a dependency
+
+Usage:
+        gvt replace [-symlink] importpath dir | -drop [-precaire] importpath
+
+replace takes a vendored dependency from a local directory instead of its repository.
+
+The directory is expected to hold the code of the import path, for example a
+checkout of its repository. The repository and revision in the manifest are kept,
+and restore will copy the dependency from the directory until the replacement is
+dropped. Replaced dependencies are skipped by update.
+
+Flags:
+	-symlink
+		make the vendor folder a symbolic link to the directory instead of a copy.
+	-drop
+		drop the replacement and restore the dependency from its repository.
+	-precaire
+		allow the use of insecure protocols.
+
+Report dependencies behind their upstream
+
+Usage:
+        gvt outdated [-json] [-precaire] [-connections N] [importpath...]
+
+outdated reports how far dependencies are behind their upstream, without changing them.
+
+For each dependency it prints the vendored revision and the date of its commit,
+the revision gvt update would move to, and the number of commits in between.
+Dependencies fetched with -tag or -version are compared against the newest
+matching tag, the others against the head of their branch.
+
+If no import path is supplied, all dependencies are reported.
+
+Flags:
+	-json
+		print the report as JSON.
+	-precaire
+		allow the use of insecure protocols.
+	-connections
+		count of parallel download connections.
+
+Remove unused vendored packages
+
+Usage:
+        gvt prune [-dry-run] [-keep importpath,...] [-strict]
+
+prune removes the dependencies and the vendored packages that the project does not use.
+
+It parses the packages of the project, including their tests, and follows
+their imports through the vendor folder. Only the files matching the build
+tags recorded by gvt fetch -tags are parsed. Dependencies of which no package is
+reachable are deleted from the vendor folder and the manifest, and the
+unreachable packages of the other dependencies are removed.
+
+Packages which are only imported by files excluded from the parsing, like
+cgo helpers, can be protected with -keep. Keeping a path keeps the packages
+below it too.
+
+Flags:
+	-dry-run
+		only print what would be removed, without changing anything.
+	-keep
+		comma separated list of import paths to keep.
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+List dependencies nothing imports
+
+Usage:
+        gvt unused [-strict]
+
+unused lists the dependencies of the manifest of which no package is imported
+by the project, directly or through other vendored packages.
+
+It exits with an error if any dependency is unused, so that it can be used to
+check the vendor folder in continuous integration. gvt prune removes them.
+
+Flags:
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+List imports which are not vendored
+
+Usage:
+        gvt missing [-strict]
+
+missing lists the imports of the project, and of the vendored packages it uses,
+which are neither vendored, part of the project nor part of the standard library.
+
+It exits with an error if any import is missing, so that it can be used to
+check the vendor folder in continuous integration.
+
+Flags:
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+Print the dependency graph
+
+Usage:
+        gvt graph [-format dot|json|tree] [-strict] [importpath]
+
+graph prints the graph of the dependencies of the project.
+
+The graph is computed from the imports of the project packages, including
+their tests, followed through the vendor folder. Each node is a dependency of
+the manifest, or the project itself, and there is an edge from a node to
+another when one of its packages imports a package of the other.
+
+If an import path is supplied, only the part of the graph reachable from its
+dependency is printed.
+
+Flags:
+	-format
+		dot prints a graph for Graphviz, json the list of dependencies with
+		their imports, the packages importing them and the dependency they
+		were fetched for, and tree (the default) an indented tree.
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+Explain why a package is vendored
+
+Usage:
+        gvt why [-strict] importpath
+
+why prints the shortest chains of imports from the packages of the project to
+the vendored packages at or below the import path.
+
+A chain is printed for each such package the project uses, starting with a
+project package and ending with the vendored package. If the project does not
+use any of them, why reports that they are not needed.
+
+Flags:
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+Report the licenses of the dependencies
+
+Usage:
+        gvt licenses [-format text|csv|json]
+
+licenses reports the license of each dependency.
+
+Licenses are detected from the license files of the dependencies, like LICENSE
+or COPYING, when they are fetched or updated, and recorded in the manifest.
+They are reported as SPDX identifiers, like MIT or Apache-2.0; "unknown" is
+reported for license files which are not recognized.
+
+If the vendor folder holds a licensepolicy file, gvt fetch and gvt update refuse
+dependencies without a license or with a license the policy does not allow.
+Each line of the file is "allow" or "deny" followed by a license identifier;
+when there are allow lines, only those licenses are allowed. licenses then also
+reports the dependencies breaking the policy, and exits with an error if any.
+
+Flags:
+	-format
+		text (the default) prints a table, csv and json print the same
+		fields in those formats.
+
+Generate a third party notices document
+
+Usage:
+        gvt notices [-o file] [-format text|markdown]
+
+notices generates a document with the license and notice files of all the
+dependencies, like a THIRD_PARTY_NOTICES file to ship with a release.
+
+For each dependency it includes the repository and revision it was vendored
+from, its license, and the license files copied to its vendor folder, like
+LICENSE, NOTICE or PATENTS.
+
+Flags:
+	-o file
+		write the document to file instead of the standard output.
+	-format
+		text (the default) or markdown.
+
+Export a software bill of materials
+
+Usage:
+        gvt sbom [-format spdx-json|cyclonedx-json] [-o file]
+
+sbom exports the dependencies of the manifest as a software bill of materials.
+
+Each dependency is described by its import path, version (its tag, or else its
+revision), repository, license and a SHA-256 hash of its vendor folder.
+Dependencies which are not vendored, before a "gvt restore", have no hash and
+the license recorded in the manifest. The project is the root component,
+depending on all of them.
+
+Flags:
+	-format
+		spdx-json (the default) for an SPDX 2.3 document, or cyclonedx-json
+		for a CycloneDX 1.4 one.
+	-o file
+		write the bill of materials to file instead of the standard output.
+
+Check dependencies against security advisories
+
+Usage:
+        gvt audit -db file|dir [-strict] [-history [-precaire]] [importpath...]
+
+audit checks the dependencies against a local database of security advisories
+in the OSV format, like a copy of the Go vulnerability database.
+
+An advisory concerns a dependency when one of its affected packages is inside
+the dependency, or contains it. The dependency is affected if its tag, or else
+the module version it was pinned at by another dependency, is in the affected
+versions, or if its revision is an affected commit. A dependency for which
+neither its version nor its revision decides is reported as possibly affected.
+With -history, the repository of such a dependency is downloaded to find
+whether its revision descends from the affected commits. Without it, audit
+works offline.
+
+audit prints the advisories of each affected dependency, and exits with an
+error if there are any, so that it can be used in continuous integration.
+Possibly affected dependencies only make it fail with -strict.
+
+If no import path is supplied, all dependencies are checked.
+
+Flags:
+	-db
+		a JSON file of advisories, or a folder of such files.
+	-strict
+		also fail when dependencies are possibly affected.
+	-history
+		download the repositories of possibly affected dependencies to
+		evaluate the commit ranges of the advisories.
+	-precaire
+		allow the use of insecure protocols.
+
+Import dependencies from another vendoring tool
+
+Usage:
+        gvt import [-from godep|glide|govendor|dep|gomod] [-restore] [-precaire] [-connections N]
+
+import adds the dependencies pinned by the manifest of another vendoring tool
+to the manifest, to adopt a project managed by that tool.
+
+The manifest is read from the project folder:
+
+    godep       Godeps/Godeps.json
+    glide       glide.lock
+    govendor    vendor/vendor.json
+    dep         Gopkg.lock
+    gomod       go.mod
+
+Dependencies are vendored as their repositories, at the pinned revisions or
+tags. The repositories are deduced from the import paths, or from the sources
+recorded by the tool, like forks. A go.mod replacement by a local folder is
+recorded as with "gvt replace", on top of the required version. A module of
+major version 2 or more is taken from the vN subfolder of its repository if it
+holds the module, or else from the root of the repository, as with fetch.
+Dependencies already in the manifest are kept.
+
+Only the manifest is written, use -restore or "gvt restore" to fetch the code.
+
+Flags:
+	-from
+		the format of the manifest; by default the first one found is used,
+		in the order above.
+	-restore
+		restore the dependencies after importing them.
+	-precaire
+		allow the use of insecure protocols.
+	-connections
+		count of parallel download connections.
+
+Export the manifest for another dependency manager
+
+Usage:
+        gvt export [-format gomod] [-o file] [-module path] [-go version] [-precaire]
+
+export converts the manifest for another dependency manager, to migrate
+away from vendoring in GOPATH.
+
+The gomod format is a go.mod file for Go modules. A dependency fetched from a
+Go module is required as that module, at the version recorded when fetching it.
+Otherwise the repository it is vendored from is required as a module, at its
+tag if it is a semantic version, or else at the pseudo-version of its revision,
+which is computed from the date of the commit. Modules of major version 2 or
+more without a /vN suffix are required as +incompatible.
+
+Dependencies which are not taken from the repository of their import path get
+a replace directive: those rewritten by a mirror rule are replaced by the
+mirror, those fetched from a fork with "gvt fetch -repo" by the fork, and those
+replaced by a local folder with "gvt replace" by that folder.
+
+Computing pseudo-versions and recognizing forks of vanity import paths may
+require to download the repositories.
+
+Flags:
+	-format
+		gomod, the default and only format.
+	-o file
+		write the export to file instead of the standard output.
+	-module path
+		the module path of the project; by default its import path in GOPATH.
+	-go version
+		add a go directive for this Go version.
+	-precaire
+		allow the use of insecure protocols.
+
 */
 package main
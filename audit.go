@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	auditDB      string // path of the advisory database
+	auditStrict  bool   // fail on possibly affected dependencies
+	auditHistory bool   // download repositories to evaluate commit ranges
+)
+
+func addAuditFlags(fs *flag.FlagSet) {
+	fs.StringVar(&auditDB, "db", "", "file or folder of the OSV advisory database")
+	fs.BoolVar(&auditStrict, "strict", false, "fail on possibly affected dependencies")
+	fs.BoolVar(&auditHistory, "history", false, "download repositories to evaluate commit ranges")
+	fs.BoolVar(&insecure, "precaire", false, "allow the use of insecure protocols")
+}
+
+var cmdAudit = &Command{
+	Name:      "audit",
+	UsageLine: "audit -db file|dir [-strict] [-history [-precaire]] [importpath...]",
+	Short:     "check dependencies against security advisories",
+	Long: `audit checks the dependencies against a local database of security advisories
+in the OSV format, like a copy of the Go vulnerability database.
+
+An advisory concerns a dependency when one of its affected packages is inside
+the dependency, or contains it. The dependency is affected if its tag, or else
+the module version it was pinned at by another dependency, is in the affected
+versions, or if its revision is an affected commit. A dependency for which
+neither its version nor its revision decides is reported as possibly affected.
+With -history, the repository of such a dependency is downloaded to find
+whether its revision descends from the affected commits. Without it, audit
+works offline.
+
+audit prints the advisories of each affected dependency, and exits with an
+error if there are any, so that it can be used in continuous integration.
+Possibly affected dependencies only make it fail with -strict.
+
+If no import path is supplied, all dependencies are checked.
+
+Flags:
+	-db
+		a JSON file of advisories, or a folder of such files.
+	-strict
+		also fail when dependencies are possibly affected.
+	-history
+		download the repositories of possibly affected dependencies to
+		evaluate the commit ranges of the advisories.
+	-precaire
+		allow the use of insecure protocols.
+
+`,
+	Run: func(args []string) error {
+		if auditDB == "" {
+			return fmt.Errorf("audit: the advisory database is missing, use -db")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		dependencies := m.Dependencies
+		if len(args) > 0 {
+			dependencies = nil
+			for _, p := range args {
+				dependency, err := m.GetDependencyForImportpath(p)
+				if err != nil {
+					return fmt.Errorf("could not get dependency: %v", err)
+				}
+				dependencies = append(dependencies, dependency)
+			}
+		}
+
+		advisories, err := vendor.ReadAdvisories(auditDB)
+		if err != nil {
+			return fmt.Errorf("could not read advisories: %v", err)
+		}
+
+		var affected, possibly int
+		for _, d := range dependencies {
+			version := d.Tag
+			if version == "" {
+				version = shortRev(d.Revision)
+			}
+			printed := false
+			history := dependencyHistory(d)
+			for _, a := range advisories {
+				exposure, fixed := auditDependency(a, d, history)
+				if exposure == vendor.NotAffected {
+					continue
+				}
+				if !printed {
+					fmt.Printf("%s (%s)\n", d.Importpath, version)
+					printed = true
+				}
+				id := a.ID
+				if len(a.Aliases) > 0 {
+					id += " (" + strings.Join(a.Aliases, ", ") + ")"
+				}
+				if exposure == vendor.PossiblyAffected {
+					id += " possibly affected"
+					possibly++
+				} else {
+					affected++
+				}
+				fmt.Printf("  %s: %s\n", id, a.Summary)
+				if len(fixed) > 0 {
+					fmt.Printf("    fixed in %s\n", strings.Join(fixed, ", "))
+				}
+			}
+		}
+
+		switch {
+		case affected > 0 || auditStrict && possibly > 0:
+			return fmt.Errorf("%d advisories affect the dependencies, %d possibly", affected+possibly, possibly)
+		case possibly > 0:
+			fmt.Printf("%d advisories possibly affect the dependencies\n", possibly)
+		}
+		return nil
+	},
+	AddFlags: addAuditFlags,
+}
+
+// auditDependency returns how much the advisory a concerns dependency d, and
+// the versions fixing it. history returns the history of the repository of
+// d, or nil if it is not available.
+func auditDependency(a vendor.Advisory, d vendor.Dependency, history func() vendor.History) (vendor.Exposure, []string) {
+	version := d.Tag
+	if _, err := vendor.ParseVersion(version); err != nil {
+		version = d.ModuleVersion
+	}
+	exposure := vendor.NotAffected
+	var fixed []string
+	for i := range a.Affected {
+		p := &a.Affected[i]
+		if !p.Matches(d.Importpath) {
+			continue
+		}
+		e := p.Check(version, d.Revision, nil)
+		if e == vendor.PossiblyAffected && d.Revision != "" && hasGitRange(p) {
+			// evaluate the git ranges with the history of the repository
+			if h := history(); h != nil {
+				e = p.Check(version, d.Revision, h)
+			}
+		}
+		if e > exposure {
+			exposure = e
+			fixed = p.FixedVersions()
+		}
+	}
+	return exposure, fixed
+}
+
+// hasGitRange reports whether the affected package has a range of commits.
+func hasGitRange(p *vendor.AffectedPackage) bool {
+	for _, r := range p.Ranges {
+		if r.Type == "GIT" {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyHistory returns a function downloading the history of the
+// repository of dependency d once, with -history. It returns nil if the
+// history is not available.
+func dependencyHistory(d vendor.Dependency) func() vendor.History {
+	var (
+		h    vendor.History
+		done bool
+	)
+	return func() vendor.History {
+		if done || !auditHistory || d.Repository == "" {
+			return h
+		}
+		done = true
+		repo, err := vendor.NewRemoteRepo(d.Repository, d.VCS, insecure)
+		if err != nil {
+			log.Printf("%s: %v", d.Importpath, err)
+			return nil
+		}
+		wc, err := GlobalDownloader.Get(repo, "", "", d.Revision, false)
+		if err != nil {
+			log.Printf("could not download %s: %v", d.Importpath, err)
+			return nil
+		}
+		h, _ = wc.(vendor.History)
+		return h
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/uk702/gvt/fileutils"
+	"github.com/uk702/gvt/gbvendor"
+)
+
+func addDiffFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&insecure, "precaire", false, "allow the use of insecure protocols")
+}
+
+var cmdDiff = &Command{
+	Name:      "diff",
+	UsageLine: "diff [-precaire] [importpath]",
+	Short:     "show local modifications of dependencies",
+	Long: `diff prints the local modifications of vendored dependencies as a unified diff.
+
+The revision recorded in the manifest is checked out, the same files that fetch
+would copy are selected, and the result is compared against the vendor folder.
+If no import path is supplied, all dependencies are compared.
+
+Flags:
+	-precaire
+		allow the use of insecure protocols.
+
+`,
+	Run: func(args []string) error {
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		var dependencies []vendor.Dependency
+		switch len(args) {
+		case 0:
+			dependencies = m.Dependencies
+		case 1:
+			dependency, err := m.GetDependencyForImportpath(args[0])
+			if err != nil {
+				return fmt.Errorf("could not get dependency: %v", err)
+			}
+			dependencies = append(dependencies, dependency)
+		default:
+			return fmt.Errorf("more than one import path supplied")
+		}
+
+		for _, d := range dependencies {
+			upstream, err := upstreamCopy(d)
+			if err != nil {
+				return fmt.Errorf("%s: %v", d.Importpath, err)
+			}
+			local := filepath.Join(vendorDir, filepath.FromSlash(d.Importpath))
+			_, err = diffDirs(os.Stdout, upstream, local, d.Importpath+"/")
+			fileutils.RemoveAll(upstream)
+			if err != nil {
+				return fmt.Errorf("%s: %v", d.Importpath, err)
+			}
+		}
+		return nil
+	},
+	AddFlags: addDiffFlags,
+}
+
+// upstreamCopy checks out the manifest revision of dep and copies it to a new
+// temporary directory, selecting files the same way fetch does.
+// The caller is responsible for removing the directory.
+func upstreamCopy(dep vendor.Dependency) (string, error) {
+	repo, err := vendor.NewRemoteRepo(dep.Repository, dep.VCS, insecure)
+	if err != nil {
+		return "", fmt.Errorf("could not determine repository for import %q", dep.Importpath)
+	}
+
+	wc, err := GlobalDownloader.Get(repo, "", "", dep.Revision, false)
+	if err != nil {
+		return "", fmt.Errorf("dependency could not be fetched: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "gvt-")
+	if err != nil {
+		return "", err
+	}
+
+	src := filepath.Join(wc.Dir(), dep.Path)
+	if err := fileutils.Copypath(dir, src, !dep.NoTests, dep.AllFiles); err != nil {
+		fileutils.RemoveAll(dir)
+		return "", err
+	}
+
+	if err := fileutils.CopyLicense(dir, src, wc.Dir()); err != nil {
+		fileutils.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// diffDirs writes a unified diff from directory a to directory b to w. File
+// names are relative to a and b, and prefixed with prefix. It reports whether
+// any difference was found.
+func diffDirs(w io.Writer, a, b, prefix string, extraArgs ...string) (bool, error) {
+	args := []string{"diff", "--no-index", "--no-color", "--no-ext-diff", "--no-renames"}
+	args = append(args, extraArgs...)
+	args = append(args, a, b)
+
+	var buf bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &buf
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err == nil {
+		return false, nil
+	}
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		return false, fmt.Errorf("git diff failed: %v", err)
+	}
+
+	// git prints the directories as given, without their leading separator
+	// after the a/ and b/ prefixes of a patch.
+	out := buf.String()
+	for _, dir := range []string{a, b} {
+		dir = strings.TrimLeft(filepath.ToSlash(strings.TrimPrefix(dir, filepath.VolumeName(dir))), "/")
+		out = strings.Replace(out, "a/"+dir+"/", "a/"+prefix, -1)
+		out = strings.Replace(out, "b/"+dir+"/", "b/"+prefix, -1)
+		out = strings.Replace(out, "/"+dir+"/", prefix, -1)
+	}
+	_, err = io.WriteString(w, out)
+	return true, err
+}
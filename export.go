@@ -0,0 +1,230 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	exportFormat string // output format
+	exportOutput string // file to write
+	exportModule string // module path of the project
+	exportGo     string // Go version of the go directive
+)
+
+func addExportFlags(fs *flag.FlagSet) {
+	fs.StringVar(&exportFormat, "format", "gomod", "output format: gomod")
+	fs.StringVar(&exportOutput, "o", "", "file to write the export to")
+	fs.StringVar(&exportModule, "module", "", "module path of the project")
+	fs.StringVar(&exportGo, "go", "", "Go version of the go directive")
+	fs.BoolVar(&insecure, "precaire", false, "allow the use of insecure protocols")
+}
+
+var cmdExport = &Command{
+	Name:      "export",
+	UsageLine: "export [-format gomod] [-o file] [-module path] [-go version] [-precaire]",
+	Short:     "export the manifest for another dependency manager",
+	Long: `export converts the manifest for another dependency manager, to migrate
+away from vendoring in GOPATH.
+
+The gomod format is a go.mod file for Go modules. A dependency fetched from a
+Go module is required as that module, at the version recorded when fetching it.
+Otherwise the repository it is vendored from is required as a module, at its
+tag if it is a semantic version, or else at the pseudo-version of its revision,
+which is computed from the date of the commit. Modules of major version 2 or
+more without a /vN suffix are required as +incompatible.
+
+Dependencies which are not taken from the repository of their import path get
+a replace directive: those rewritten by a mirror rule are replaced by the
+mirror, those fetched from a fork with "gvt fetch -repo" by the fork, and those
+replaced by a local folder with "gvt replace" by that folder.
+
+Computing pseudo-versions and recognizing forks of vanity import paths may
+require to download the repositories.
+
+Flags:
+	-format
+		gomod, the default and only format.
+	-o file
+		write the export to file instead of the standard output.
+	-module path
+		the module path of the project; by default its import path in GOPATH.
+	-go version
+		add a go directive for this Go version.
+	-precaire
+		allow the use of insecure protocols.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("export: unexpected arguments")
+		}
+		if exportFormat != "gomod" {
+			return fmt.Errorf("unknown format %q", exportFormat)
+		}
+		module := exportModule
+		if module == "" {
+			module = importPath
+		}
+		if module == "" {
+			return fmt.Errorf("the project is not in GOPATH, use -module to set its module path")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		gomod := &vendor.GoMod{Module: module, Go: exportGo}
+		revisions := make(map[string]string)
+		for _, d := range m.Dependencies {
+			mod := d.Module
+			if mod == "" {
+				mod = moduleRoot(d)
+			}
+			if rev, ok := revisions[mod]; ok {
+				if rev != d.Revision {
+					log.Printf("%s: packages vendored at different revisions, using %s", mod, shortRev(rev))
+				}
+				continue
+			}
+			revisions[mod] = d.Revision
+
+			version := d.ModuleVersion
+			if version == "" {
+				if version, err = moduleVersion(mod, d); err != nil {
+					return fmt.Errorf("could not compute the version of %s: %v", mod, err)
+				}
+			}
+			gomod.Require = append(gomod.Require, vendor.ModuleVersion{Path: mod, Version: version})
+
+			if d.Replace != "" {
+				gomod.Replace = append(gomod.Replace, vendor.ModuleReplace{
+					Old: vendor.ModuleVersion{Path: mod},
+					New: vendor.ModuleVersion{Path: localModPath(d.Replace)},
+				})
+				continue
+			}
+			source, err := moduleSource(mod, d)
+			if err != nil {
+				return fmt.Errorf("could not determine the repository of %s: %v", mod, err)
+			}
+			if source != "" {
+				gomod.Replace = append(gomod.Replace, vendor.ModuleReplace{
+					Old: vendor.ModuleVersion{Path: mod},
+					New: vendor.ModuleVersion{Path: source, Version: version},
+				})
+			}
+		}
+
+		if exportOutput == "" {
+			_, err := os.Stdout.Write(gomod.Format())
+			return err
+		}
+		return ioutil.WriteFile(exportOutput, gomod.Format(), 0644)
+	},
+	AddFlags: addExportFlags,
+}
+
+// moduleRoot returns the import path of the repository root of dependency
+// d, which is its module path when it was not fetched from a module.
+func moduleRoot(d vendor.Dependency) string {
+	if d.Path != "" && strings.HasSuffix(d.Importpath, d.Path) {
+		return strings.TrimSuffix(d.Importpath, d.Path)
+	}
+	return d.Importpath
+}
+
+// moduleVersion returns the module version of dependency d: its tag if it is
+// a semantic version of the major version of the module, or else the
+// pseudo-version of its revision.
+func moduleVersion(mod string, d vendor.Dependency) (string, error) {
+	major, suffixed := vendor.PathMajor(mod)
+	if d.Repository == "" {
+		// only known from its local replacement
+		return vendor.PseudoVersion(major, time.Time{}, "000000000000"), nil
+	}
+	if v, err := vendor.ParseVersion(d.Tag); err == nil && d.Tag == "v"+v.String() {
+		switch {
+		case !suffixed && v.Major >= 2:
+			return d.Tag + "+incompatible", nil
+		case !suffixed || v.Major == major:
+			return d.Tag, nil
+		}
+	}
+
+	repo, err := vendor.NewRemoteRepo(d.Repository, d.VCS, insecure)
+	if err != nil {
+		return "", err
+	}
+	wc, err := GlobalDownloader.Get(repo, "", "", d.Revision, false)
+	if err != nil {
+		return "", err
+	}
+	history, ok := wc.(vendor.History)
+	if !ok {
+		return "", fmt.Errorf("history of %s repositories is not supported", d.VCS)
+	}
+	commit, err := history.Commit(d.Revision)
+	if err != nil {
+		return "", err
+	}
+	return vendor.PseudoVersion(major, commit.Date, commit.Revision), nil
+}
+
+// moduleSource returns the path module mod of dependency d is taken from, if
+// it is not its own repository: the path of its mirror rule, or its fork.
+func moduleSource(mod string, d vendor.Dependency) (string, error) {
+	mirror, _ := replaceMirrorPath(mod)
+	repo := repositoryPath(d.Repository)
+	switch repo {
+	case mod:
+		return "", nil
+	case mirror:
+		return mirror, nil
+	}
+
+	// a vanity import path, or a fork
+	r, _, err := GlobalDownloader.DeduceRemoteRepo(mirror, insecure)
+	if err != nil {
+		return "", err
+	}
+	if repositoryPath(r.URL()) == repo {
+		if mirror == mod {
+			return "", nil
+		}
+		return mirror, nil
+	}
+	return repo, nil
+}
+
+// repositoryPath returns a repository URL in the form of an import path,
+// without scheme, user and .git suffix.
+func repositoryPath(repoURL string) string {
+	if u, err := url.Parse(repoURL); err == nil && u.Host != "" {
+		repoURL = u.Host + u.Path
+	} else if i := strings.Index(repoURL, "@"); i >= 0 {
+		// scp like syntax, user@host:path
+		repoURL = strings.Replace(repoURL[i+1:], ":", "/", 1)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+}
+
+// localModPath returns the path of a local replacement relative to the
+// go.mod file, in the ./dir form required by go.mod.
+func localModPath(dir string) string {
+	dir = filepath.ToSlash(dir)
+	if filepath.IsAbs(dir) || strings.HasPrefix(dir, "./") || strings.HasPrefix(dir, "../") {
+		return dir
+	}
+	return "./" + dir
+}
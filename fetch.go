@@ -15,13 +15,17 @@ import (
 )
 
 var (
-	branch    string
-	revision  string // revision (commit)
-	tag       string
-	noRecurse bool
-	insecure  bool // Allow the use of insecure protocols
-	tests     bool
-	all       bool
+	branch       string
+	revision     string // revision (commit)
+	tag          string
+	fetchRepo    string // repository to fetch from instead of the import path one
+	fetchVersion string // semantic version constraint
+	fetchTags    string // build tags selecting the files parsed for imports
+	fetchLatest  bool   // ignore the versions pinned by fetched dependencies
+	noRecurse    bool
+	insecure     bool // Allow the use of insecure protocols
+	tests        bool
+	all          bool
 
 	// Lilx
 	verbose bool
@@ -31,22 +35,46 @@ func addFetchFlags(fs *flag.FlagSet) {
 	fs.StringVar(&branch, "branch", "", "branch of the package")
 	fs.StringVar(&revision, "revision", "", "revision of the package")
 	fs.StringVar(&tag, "tag", "", "tag of the package")
+	fs.StringVar(&fetchRepo, "repo", "", "repository to fetch the package from")
+	fs.StringVar(&fetchVersion, "version", "", "semantic version constraint of the package")
+	fs.StringVar(&fetchTags, "tags", "", "build tags selecting the files parsed for imports")
 	fs.BoolVar(&noRecurse, "no-recurse", false, "do not fetch recursively")
+	fs.BoolVar(&fetchLatest, "latest", false, "fetch recursive dependencies at their latest revision")
 	fs.BoolVar(&insecure, "precaire", false, "allow the use of insecure protocols")
 	fs.BoolVar(&tests, "t", false, "fetch _test.go files and testdata")
 	fs.BoolVar(&all, "a", false, "fetch all files and subfolders")
 	fs.BoolVar(&verbose, "v", false, "verbose show checkout progress")
+	addStrictFlag(fs)
 }
 
 var cmdFetch = &Command{
 	Name:      "fetch",
-	UsageLine: "fetch [-branch branch] [-revision rev | -tag tag] [-precaire] [-no-recurse] [-t|-a] importpath",
+	UsageLine: "fetch [-branch branch] [-revision rev | -tag tag | -version constraint] [-repo url] [-tags tags] [-precaire] [-strict] [-no-recurse | -latest] [-t|-a] importpath",
 	Short:     "fetch a remote dependency",
 	Long: `fetch vendors an upstream import path.
 
 Recursive dependencies are fetched (at their master/tip/HEAD revision), unless they
 or their parent package are already present.
 
+Recursive dependencies pinned by a fetched dependency are fetched at the pinned
+version instead, unless -latest is given. Pins are read from the go.mod file of
+the module of the dependency, for the modules it requires, and from its
+vendor/manifest file or the lock file of another vendoring tool, as listed by
+"gvt help import". Replacements by other modules or forks are followed too.
+When several dependencies pin the same one, the highest version wins over the
+others and over plain revisions. Pins are ignored for packages of the
+repository of the fetched import path, which are taken at its revision.
+
+Import paths with a major version suffix, like example.com/mod/v2/pkg, are
+taken from the v2 folder of the repository if it holds the module, or else
+from its newest v2 tag or its v2 branch. The module of a dependency and its
+version are recorded in the manifest.
+
+Imports of the standard library, the packages of GOROOT/src, are not fetched;
+any other import is. An importRules file, in the current or in the home
+directory, can change that: each line holds an import path prefix followed by
+"fetch" or "ignore", like "corp/ ignore" for packages provided by other means.
+
 If a subpackage of a dependency being fetched is already present, it will be deleted.
 
 The import path may include a url scheme. This may be useful when fetching dependencies
@@ -62,13 +90,33 @@ Flags:
 		If not supplied the default upstream branch will be used.
 	-no-recurse
 		do not fetch recursively.
+	-latest
+		fetch recursive dependencies at their latest revision, ignoring the
+		versions pinned by the fetched dependencies.
 	-tag tag
-		fetch the specified tag.
+		fetch the specified tag. gvt update will move to newer tags.
+	-version constraint
+		fetch the newest tag matching the semantic version constraint, like
+		"^1.4" or "~1.2". Will also be used by gvt update.
+	-tags tags
+		only look for imports in the files built with the comma separated
+		build tags, like "linux,amd64,!appengine". GOOS and GOARCH values
+		are tags too; when none is given all of them are considered, but
+		the negated ones. The tags are recorded in the manifest and also
+		used by gvt update, prune, unused and missing.
 	-revision rev
 		fetch the specific revision from the branch or repository.
 		If no revision supplied, the latest available will be fetched.
+	-repo url
+		fetch from the given repository, for example a fork, instead of the one
+		of the import path. The import path is kept, and gvt update will follow
+		the repository. A url with a scheme is taken as the root of the
+		repository, anything else is resolved like an import path.
 	-precaire
 		allow the use of insecure protocols.
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
 
 `,
 	Run: func(args []string) error {
@@ -95,11 +143,22 @@ var (
 )
 
 func fetch(path string) error {
+	if fetchVersion != "" && (branch != "" || tag != "" || revision != "") {
+		return fmt.Errorf("-version cannot be used with -branch, -tag or -revision")
+	}
+
 	m, err := vendor.ReadManifest(manifestFile)
 	if err != nil {
 		return fmt.Errorf("could not load manifest: %v", err)
 	}
 
+	if fetchTags != "" {
+		if _, err := vendor.ParseBuildTags(fetchTags); err != nil {
+			return err
+		}
+		m.BuildTags = fetchTags
+	}
+
 	// replaceMirrorPath("gopkg.in/check")
 	// replaceMirrorPath("gopkg.in/check.v1")
 	// replaceMirrorPath("gopkg.in/check.v1/")
@@ -126,7 +185,7 @@ func fetch(path string) error {
 					// fmt.Println(line)
 
 					if len(strings.TrimSpace(line)) > 0 {
-						err = fetchRecursive(m, line, 0)
+						err = fetchRecursive(m, line, "", 0)
 						if err != nil {
 							fmt.Println(err)
 						}
@@ -140,7 +199,7 @@ func fetch(path string) error {
 	}
 
 	fetchRoot = stripscheme(path)
-	err = fetchRecursive(m, path, 0)
+	err = fetchRecursive(m, path, "", 0)
 
 	return err
 }
@@ -183,7 +242,9 @@ func replaceMirrorPath(fullPath string) (string, string) {
 	return fullPath, branch
 }
 
-func fetchRecursive(m *vendor.Manifest, fullPath string, level int) error {
+// fetchRecursive fetches fullPath, then its imports. parent is the
+// dependency importing it, if any.
+func fetchRecursive(m *vendor.Manifest, fullPath, parent string, level int) error {
 	path := stripscheme(fullPath)
 
 	// Lilx
@@ -246,18 +307,40 @@ func fetchRecursive(m *vendor.Manifest, fullPath string, level int) error {
 		return fmt.Errorf("failed to remove existing folder: %v", err)
 	}
 
+	// Dependencies pinned by a fetched dependency are taken at their
+	// version, and from their replacement
+	fetchPath := fullPath
+	mod, pin := pinnedDependency(path)
+	if level == 0 {
+		pin = nil
+	}
+	if pin != nil && pin.source != "" {
+		fetchPath = pin.source + strings.TrimPrefix(path, mod)
+	}
+
 	// Find and download the repository
-	replacePathWithMirror, replaceBranch := replaceMirrorPath(fullPath)
+	replacePathWithMirror, replaceBranch := replaceMirrorPath(fetchPath)
 	// fmt.Println("replacePathWithMirror = " + replacePathWithMirror)
 
-	repo, extra, err := GlobalDownloader.DeduceRemoteRepo(replacePathWithMirror, insecure)
-	if (err != nil) {
-		fmt.Println("download " + path + " fail, retry.")
-    	repo, extra, err = GlobalDownloader.DeduceRemoteRepo(replacePathWithMirror, insecure)
-
+	var (
+		repo  vendor.RemoteRepo
+		extra string
+		err   error
+	)
+	if level == 0 && fetchRepo != "" {
+		// keep the import path, but take the code from another repository
+		replaceBranch = ""
+		repo, extra, err = vendor.ForkRemoteRepo(fetchRepo, path, insecure)
+	} else {
+		repo, extra, err = GlobalDownloader.DeduceRemoteRepo(replacePathWithMirror, insecure)
 		if (err != nil) {
 			fmt.Println("download " + path + " fail, retry.")
 			repo, extra, err = GlobalDownloader.DeduceRemoteRepo(replacePathWithMirror, insecure)
+
+			if (err != nil) {
+				fmt.Println("download " + path + " fail, retry.")
+				repo, extra, err = GlobalDownloader.DeduceRemoteRepo(replacePathWithMirror, insecure)
+			}
 		}
 	}
 
@@ -278,32 +361,32 @@ func fetchRecursive(m *vendor.Manifest, fullPath string, level int) error {
 	}
 
 	var wc vendor.WorkingCopy
+	var depTag, depConstraint string
 	if repo.URL() == rootRepoURL {
 		if branch != "" {
 			replaceBranch = branch
 		}
-
-		wc, err = GlobalDownloader.Get(repo, replaceBranch, tag, revision, verbose)
-		if (err != nil) {
-			fmt.Println("download " + path + " fail, retry.")
-			wc, err = GlobalDownloader.Get(repo, replaceBranch, tag, revision, verbose)
-
-			if (err != nil) {
-				fmt.Println("download " + path + " fail, retry.")
-				wc, err = GlobalDownloader.Get(repo, replaceBranch, tag, revision, verbose)
+		depTag = tag
+		if fetchVersion != "" {
+			if depTag, err = semverTag(repo, fetchVersion); err != nil {
+				return err
 			}
+			depConstraint = fetchVersion
+			replaceBranch = ""
 		}
-	} else {
-		wc, err = GlobalDownloader.Get(repo, replaceBranch, "", "", verbose)
+
+		wc, err = GlobalDownloader.Get(repo, replaceBranch, depTag, revision, verbose)
 		if (err != nil) {
 			fmt.Println("download " + path + " fail, retry.")
-			wc, err = GlobalDownloader.Get(repo, replaceBranch, "", "", verbose)
+			wc, err = GlobalDownloader.Get(repo, replaceBranch, depTag, revision, verbose)
 
 			if (err != nil) {
 				fmt.Println("download " + path + " fail, retry.")
-				wc, err = GlobalDownloader.Get(repo, replaceBranch, "", "", verbose)
+				wc, err = GlobalDownloader.Get(repo, replaceBranch, depTag, revision, verbose)
 			}
 		}
+	} else {
+		wc, extra, depTag, err = checkoutModule(path, repo, replaceBranch, extra, pin, strings.TrimPrefix(path, mod))
 	}
 	
 	if err != nil {
@@ -328,15 +411,50 @@ func fetchRecursive(m *vendor.Manifest, fullPath string, level int) error {
 		return err
 	}
 
+	patches, err := findPatches(vendorDir, path)
+	if err != nil {
+		return err
+	}
+
+	gomod, err := readUpstreamPins(filepath.Join(wc.Dir(), extra), wc.Dir())
+	if err != nil {
+		return err
+	}
+	var module, moduleVersion string
+	if gomod != nil {
+		module = gomod.Module
+		if pin != nil && mod == module {
+			moduleVersion = pin.version
+		} else if _, err := vendor.ParseVersion(depTag); err == nil {
+			moduleVersion = depTag
+		}
+	}
+
+	licenseFiles, err := fileutils.FindLicenseFiles(filepath.Join(wc.Dir(), extra), wc.Dir())
+	if err != nil {
+		return err
+	}
+	license, err := checkLicense(path, licenseFiles)
+	if err != nil {
+		return err
+	}
+
 	dep := vendor.Dependency{
-		Importpath: path,
-		Repository: repo.URL(),
-		VCS:        repo.Type(),
-		Revision:   rev,
-		Branch:     b,
-		Path:       extra,
-		NoTests:    !tests,
-		AllFiles:   all,
+		Importpath:    path,
+		Repository:    repo.URL(),
+		VCS:           repo.Type(),
+		Revision:      rev,
+		Branch:        b,
+		Tag:           depTag,
+		Constraint:    depConstraint,
+		Path:          extra,
+		NoTests:       !tests,
+		AllFiles:      all,
+		Patches:       patches,
+		Parent:        parent,
+		License:       license,
+		Module:        module,
+		ModuleVersion: moduleVersion,
 	}
 
 	if err := m.AddDependency(dep); err != nil {
@@ -352,7 +470,11 @@ func fetchRecursive(m *vendor.Manifest, fullPath string, level int) error {
 		return err
 	}
 
-	if err := fileutils.CopyLicense(dst, wc.Dir()); err != nil {
+	if err := fileutils.CopyLicense(dst, src, wc.Dir()); err != nil {
+		return err
+	}
+
+	if err := applyPatches(dst, vendorDir, dep); err != nil {
 		return err
 	}
 
@@ -365,31 +487,47 @@ func fetchRecursive(m *vendor.Manifest, fullPath string, level int) error {
 	fetchedToday = append(fetchedToday, path)
 
 	if !noRecurse {
-		// Look for dependencies in src, not going past wc.Dir() when looking for /vendor/,
-		// knowing that wc.Dir() corresponds to rootRepoPath
-		if !strings.HasSuffix(dep.Importpath, dep.Path) {
-			return fmt.Errorf("unable to derive the root repo import path")
-		}
-		rootRepoPath := strings.TrimRight(strings.TrimSuffix(dep.Importpath, dep.Path), "/")
-		deps, err := vendor.ParseImports(src, wc.Dir(), rootRepoPath, tests, all)
-		if err != nil {
-			return fmt.Errorf("failed to parse imports: %s", err)
-		}
+		return fetchImports(m, dep, src, wc.Dir(), level)
+	}
 
-		for d := range deps {
-			if strings.Index(d, ".") == -1 { // TODO: replace this silly heuristic
-				continue
+	return nil
+}
+
+// fetchImports fetches the imports of the dependency dep, whose source is in
+// src inside the working copy wcDir, that are not vendored yet.
+func fetchImports(m *vendor.Manifest, dep vendor.Dependency, src, wcDir string, level int) error {
+	// Look for dependencies in src, not going past wcDir when looking for /vendor/,
+	// knowing that wcDir corresponds to rootRepoPath
+	if !strings.HasSuffix(dep.Importpath, dep.Path) {
+		return fmt.Errorf("unable to derive the root repo import path")
+	}
+	rootRepoPath := strings.TrimRight(strings.TrimSuffix(dep.Importpath, dep.Path), "/")
+	buildTags, err := manifestBuildTags(m)
+	if err != nil {
+		return err
+	}
+	deps, err := vendor.ParseImports(src, wcDir, rootRepoPath, !dep.NoTests, dep.AllFiles, buildTags)
+	if err = tolerateParseErrors(err); err != nil {
+		return fmt.Errorf("failed to parse imports: %s", err)
+	}
+
+	for d := range deps {
+		if !shouldFetch(d) {
+			continue
+		}
+		if err := fetchRecursive(m, d, dep.Importpath, level+1); err != nil {
+			switch err.(type) {
+			case *patchError, *licenseError:
+				return err
 			}
-			if err := fetchRecursive(m, d, level+1); err != nil {
-				if strings.HasPrefix(err.Error(), "error fetching") { // I know, ok?
-					//Lilx
-					//return err
-					continue
-				} else {
-					//Lilx
-					//return fmt.Errorf("error fetching %s: %s", d, err)
-					continue
-				}
+			if strings.HasPrefix(err.Error(), "error fetching") { // I know, ok?
+				//Lilx
+				//return err
+				continue
+			} else {
+				//Lilx
+				//return fmt.Errorf("error fetching %s: %s", d, err)
+				continue
 			}
 		}
 	}
@@ -412,6 +550,15 @@ func stripscheme(path string) string {
 	return u.Host + u.Path
 }
 
+// manifestBuildTags returns the build tags recorded in the manifest, or nil
+// when all files are parsed.
+func manifestBuildTags(m *vendor.Manifest) (*vendor.BuildTags, error) {
+	if m.BuildTags == "" {
+		return nil, nil
+	}
+	return vendor.ParseBuildTags(m.BuildTags)
+}
+
 // Package a contains package b?
 func contains(a, b string) bool {
 	return a == b || strings.HasPrefix(b, a+"/")
@@ -2,10 +2,13 @@
 package fileutils
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -24,6 +27,13 @@ var goFileTypes = []string{
 
 var licenseFiles = []string{
 	"LICENSE", "LICENCE", "UNLICENSE", "COPYING", "COPYRIGHT",
+	"NOTICE", "PATENTS", "AUTHORS",
+}
+
+// noticeFiles are the license files which go with a license without being
+// one.
+var noticeFiles = []string{
+	"NOTICE", "PATENTS", "AUTHORS",
 }
 
 func ShouldSkip(path string, info os.FileInfo, tests, all bool) bool {
@@ -176,29 +186,105 @@ func RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
 
-// CopyLicense copies the license file from folder src to folder dst.
-func CopyLicense(dst, src string) error {
-	files, err := ioutil.ReadDir(src)
+// CopyLicense copies the license files of the package in folder src to
+// folder dst. They are looked for in src and its parents up to root, the
+// root of the repository.
+func CopyLicense(dst, src, root string) error {
+	files, err := FindLicenseFiles(src, root)
 	if err != nil {
 		return err
 	}
 	for _, f := range files {
-		if f.IsDir() {
-			continue
-		}
-		for _, candidate := range licenseFiles {
-			if strings.ToLower(candidate) == strings.TrimSuffix(
-				strings.TrimSuffix(strings.ToLower(f.Name()), ".md"), ".txt") {
-				if err := Copyfile(filepath.Join(dst, f.Name()),
-					filepath.Join(src, f.Name())); err != nil {
-					return err
-				}
-			}
+		if err := Copyfile(filepath.Join(dst, filepath.Base(f)), f); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// IsLicenseFile reports whether name is the name of a license file, like
+// LICENSE, COPYING.txt or License.md.
+func IsLicenseFile(name string) bool {
+	return matchName(licenseFiles, name)
+}
+
+// IsNoticeFile reports whether name is the name of a license file which is
+// not a license itself, like NOTICE or AUTHORS.
+func IsNoticeFile(name string) bool {
+	return matchName(noticeFiles, name)
+}
+
+func matchName(candidates []string, name string) bool {
+	name = strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(name), ".md"), ".txt")
+	for _, candidate := range candidates {
+		if strings.ToLower(candidate) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LicenseFiles returns the paths of the license files in folder dir.
+func LicenseFiles(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var licenses []string
+	for _, f := range files {
+		if !f.IsDir() && IsLicenseFile(f.Name()) {
+			licenses = append(licenses, filepath.Join(dir, f.Name()))
+		}
+	}
+	return licenses, nil
+}
+
+// FindLicenseFiles returns the paths of the license files of the package in
+// folder dir, looking in dir and its parents up to root. When several files
+// have the same name, the one closest to dir is returned.
+func FindLicenseFiles(dir, root string) ([]string, error) {
+	var (
+		licenses []string
+		seen     = make(map[string]bool)
+	)
+	for {
+		files, err := LicenseFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if name := strings.ToLower(filepath.Base(f)); !seen[name] {
+				seen[name] = true
+				licenses = append(licenses, f)
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir || !strings.HasPrefix(parent, root) {
+			return licenses, nil
+		}
+		dir = parent
+	}
+}
+
+// RepositoryRoot returns the root of the repository holding folder dir, the
+// closest of dir and its parents which holds a .git, .hg or .bzr entry, or
+// dir itself if it is not in a repository.
+func RepositoryRoot(dir string) string {
+	for d := dir; ; {
+		for _, vcs := range []string{".git", ".hg", ".bzr"} {
+			if _, err := os.Stat(filepath.Join(d, vcs)); err == nil {
+				return d
+			}
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
 func mkdir(path string) error {
 	return os.MkdirAll(path, 0755)
 }
@@ -210,3 +296,79 @@ func IsFileExist(filename string) bool {
 	_, err := os.Stat(filename)
 	return err == nil || os.IsExist(err)
 }
+
+// HashDir returns the hex encoded SHA-256 hash of the contents of folder
+// dir. It hashes a sorted list of the hashes and relative paths of the
+// files, so it does not depend on timestamps or permissions. Symlinks are
+// hashed by their target.
+func HashDir(dir string) (string, error) {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fh := sha256.New()
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			io.WriteString(fh, target)
+		} else {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(fh, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(h, "%x  %s\n", fh.Sum(nil), filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// ApplyPatch applies the patch file, in the format of git diff, to the files
+// in dir. git is kept from looking for a repository above dir, like the one of
+// the project holding the vendor folder, as it would resolve the paths of the
+// patch from its root and skip them.
+func ApplyPatch(dir, patch string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if patch, err = filepath.Abs(patch); err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "apply", "--whitespace=nowarn", patch)
+	cmd.Dir = dir
+	cmd.Env = []string{"GIT_CEILING_DIRECTORIES=" + filepath.Dir(dir)}
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, "GIT_") {
+			cmd.Env = append(cmd.Env, e)
+		}
+	}
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
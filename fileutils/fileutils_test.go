@@ -3,7 +3,9 @@ package fileutils
 import (
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"testing"
 )
@@ -34,3 +36,159 @@ func mktemp(t *testing.T) string {
 	}
 	return s
 }
+
+func TestLicenseFiles(t *testing.T) {
+	dir := mktemp(t)
+	defer RemoveAll(dir)
+	for _, name := range []string{"LICENSE", "copying.txt", "License.md", "LICENSE.go", "README"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "COPYRIGHT"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := LicenseFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	if want := []string{"LICENSE", "License.md", "copying.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("LicenseFiles: want %v, got %v", want, names)
+	}
+}
+
+func TestFindLicenseFiles(t *testing.T) {
+	root := mktemp(t)
+	defer RemoveAll(root)
+	for _, name := range []string{"LICENSE", "NOTICE", "a/LICENSE", "a/PATENTS", "a/b/main.go"} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := FindLicenseFiles(filepath.Join(root, "a", "b"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(root, "a", "LICENSE"),
+		filepath.Join(root, "a", "PATENTS"),
+		filepath.Join(root, "NOTICE"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("FindLicenseFiles: want %v, got %v", want, files)
+	}
+}
+
+func TestRepositoryRoot(t *testing.T) {
+	root := mktemp(t)
+	defer RemoveAll(root)
+	for _, dir := range []string{".git", "a/b", "other"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := RepositoryRoot(filepath.Join(root, "a", "b")); got != root {
+		t.Errorf("RepositoryRoot(a/b): want %s, got %s", root, got)
+	}
+	if got := RepositoryRoot(root); got != root {
+		t.Errorf("RepositoryRoot(root): want %s, got %s", root, got)
+	}
+
+	plain := mktemp(t)
+	defer RemoveAll(plain)
+	if got := RepositoryRoot(plain); got != plain {
+		t.Errorf("RepositoryRoot outside a repository: want %s, got %s", plain, got)
+	}
+}
+
+func TestHashDir(t *testing.T) {
+	a, b := mktemp(t), mktemp(t)
+	defer RemoveAll(a)
+	defer RemoveAll(b)
+	for _, dir := range []string{a, b} {
+		if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "sub", "a.go"), []byte("package a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ha, err := HashDir(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hb, err := HashDir(b); err != nil || hb != ha {
+		t.Errorf("HashDir: same contents, different hashes %s and %s (%v)", ha, hb, err)
+	}
+
+	if err := os.Rename(filepath.Join(b, "sub", "a.go"), filepath.Join(b, "sub", "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	if hb, err := HashDir(b); err != nil || hb == ha {
+		t.Errorf("HashDir: renamed file, same hash %s (%v)", hb, err)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// the dependency is in the vendor folder of a project in a git work tree
+	root := mktemp(t)
+	defer RemoveAll(root)
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	dir := filepath.Join(root, "vendor", "example.com", "dep")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "a.go")
+	if err := ioutil.WriteFile(file, []byte("package dep\n\nconst A = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patch := filepath.Join(root, "a.patch")
+	content := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package dep
+ 
+-const A = 1
++const A = 2
+`
+	if err := ioutil.WriteFile(patch, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyPatch(dir, patch); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "package dep\n\nconst A = 2\n"; string(got) != want {
+		t.Fatalf("patched file: got %q, want %q", got, want)
+	}
+
+	// applying it again conflicts
+	if err := ApplyPatch(dir, patch); err == nil {
+		t.Fatal("expected a conflict")
+	}
+	if got, _ := ioutil.ReadFile(file); string(got) != "package dep\n\nconst A = 2\n" {
+		t.Errorf("conflicting patch changed the file: %q", got)
+	}
+}
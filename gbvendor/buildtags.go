@@ -0,0 +1,146 @@
+package vendor
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// The GOOS and GOARCH values known to go/build.
+var (
+	knownOS = []string{
+		"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js",
+		"linux", "nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos",
+	}
+	knownArch = []string{
+		"386", "amd64", "amd64p32", "arm", "armbe", "arm64", "arm64be", "loong64",
+		"mips", "mipsle", "mips64", "mips64le", "mips64p32", "mips64p32le",
+		"ppc", "ppc64", "ppc64le", "riscv", "riscv64", "s390", "s390x", "sparc", "sparc64", "wasm",
+	}
+)
+
+// BuildTags selects the Go files which are built, like the -tags flag of go
+// build, except that the GOOS and GOARCH values are tags too.
+//
+// A file matches when it would be built for one of the selected GOOS and
+// GOARCH pairs, with the other selected tags set. Without a GOOS, or without
+// a GOARCH, all the known values are selected but the negated ones.
+type BuildTags struct {
+	s    string
+	os   []string
+	arch []string
+	tags []string
+}
+
+// ParseBuildTags parses a comma or space separated list of build tags, like
+// "linux,amd64,!appengine".
+func ParseBuildTags(s string) (*BuildTags, error) {
+	t := &BuildTags{s: s}
+	off := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		name := strings.TrimPrefix(tag, "!")
+		if !validTag(name) {
+			return nil, fmt.Errorf("invalid build tag %q", tag)
+		}
+		if name != tag {
+			off[name] = true
+			continue
+		}
+		switch {
+		case isKnown(knownOS, name):
+			t.os = append(t.os, name)
+		case isKnown(knownArch, name):
+			t.arch = append(t.arch, name)
+		default:
+			t.tags = append(t.tags, name)
+		}
+	}
+
+	for _, l := range [][]string{t.os, t.arch, t.tags} {
+		for _, name := range l {
+			if off[name] {
+				return nil, fmt.Errorf("build tag %q is both set and negated", name)
+			}
+		}
+	}
+	if t.os == nil {
+		t.os = without(knownOS, off)
+	}
+	if t.arch == nil {
+		t.arch = without(knownArch, off)
+	}
+	return t, nil
+}
+
+func validTag(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func isKnown(known []string, name string) bool {
+	for _, k := range known {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+func without(known []string, off map[string]bool) []string {
+	var l []string
+	for _, k := range known {
+		if !off[k] {
+			l = append(l, k)
+		}
+	}
+	return l
+}
+
+func (t *BuildTags) String() string {
+	return t.s
+}
+
+// Match reports whether the Go file at path is built with the tags, looking
+// at its name and at its build constraints.
+func (t *BuildTags) Match(path string) (bool, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	dir, name := filepath.Split(path)
+	for _, goos := range t.os {
+		for _, goarch := range t.arch {
+			ctxt := build.Context{
+				GOOS:        goos,
+				GOARCH:      goarch,
+				CgoEnabled:  true,
+				Compiler:    "gc",
+				BuildTags:   t.tags,
+				ReleaseTags: build.Default.ReleaseTags,
+				JoinPath:    filepath.Join,
+				OpenFile: func(string) (io.ReadCloser, error) {
+					return ioutil.NopCloser(bytes.NewReader(content)), nil
+				},
+			}
+			ok, err := ctxt.MatchFile(dir, name)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,66 @@
+package vendor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/uk702/gvt/fileutils"
+)
+
+func TestParseBuildTags(t *testing.T) {
+	for _, s := range []string{"linux,!", "linux,-race", "appengine,!appengine"} {
+		if _, err := ParseBuildTags(s); err == nil {
+			t.Errorf("ParseBuildTags(%q): expected an error", s)
+		}
+	}
+	tags, err := ParseBuildTags("linux amd64,!appengine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tags.String(); got != "linux amd64,!appengine" {
+		t.Errorf("String: got %q", got)
+	}
+}
+
+func TestBuildTagsMatch(t *testing.T) {
+	dir := mktemp(t)
+	defer fileutils.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.go":           "package a\n",
+		"a_windows.go":   "package a\n",
+		"a_linux_arm.go": "package a\n",
+		"appengine.go":   "//go:build appengine\n\npackage a\n",
+		"noappengine.go": "// +build !appengine\n\npackage a\n",
+		"unix.go":        "//go:build unix && !linux\n\npackage a\n",
+		"cgo.go":         "package a\n\nimport \"C\"\n",
+	}
+	writeFiles(t, dir, files)
+
+	tests := []struct {
+		tags  string
+		match []string
+		miss  []string
+	}{
+		{"linux,amd64", []string{"a.go", "noappengine.go", "cgo.go"}, []string{"a_windows.go", "a_linux_arm.go", "appengine.go", "unix.go"}},
+		{"linux", []string{"a_linux_arm.go"}, []string{"a_windows.go", "unix.go"}},
+		{"!windows,!appengine", []string{"a.go", "a_linux_arm.go", "unix.go"}, []string{"a_windows.go", "appengine.go"}},
+		{"appengine", []string{"appengine.go", "a_windows.go"}, []string{"noappengine.go"}},
+	}
+	for _, tt := range tests {
+		tags, err := ParseBuildTags(tt.tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range tt.match {
+			if ok, err := tags.Match(filepath.Join(dir, name)); err != nil || !ok {
+				t.Errorf("%q should match %s: %v", tt.tags, name, err)
+			}
+		}
+		for _, name := range tt.miss {
+			if ok, err := tags.Match(filepath.Join(dir, name)); err != nil || ok {
+				t.Errorf("%q should not match %s: %v", tt.tags, name, err)
+			}
+		}
+	}
+}
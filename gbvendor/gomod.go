@@ -0,0 +1,279 @@
+package vendor
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoMod holds the directives of a go.mod file relevant to vendoring.
+type GoMod struct {
+	// Module is the module path.
+	Module string
+
+	// Go is the Go version of the go directive, if any.
+	Go string
+
+	// Require are the required modules, indirect ones included.
+	Require []ModuleVersion
+
+	// Replace are the replaced modules.
+	Replace []ModuleReplace
+}
+
+// ModuleVersion is a module path at a version, like v1.2.3 or a
+// pseudo-version. The version is empty for a local directory.
+type ModuleVersion struct {
+	Path    string
+	Version string
+}
+
+// ModuleReplace replaces the Old module, at any version if Old.Version is
+// empty, by the New module or local directory.
+type ModuleReplace struct {
+	Old ModuleVersion
+	New ModuleVersion
+}
+
+// ReadGoMod reads a go.mod file.
+func ReadGoMod(path string) (*GoMod, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseGoMod(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", path, err)
+	}
+	return m, nil
+}
+
+// ParseGoMod parses the content of a go.mod file. Directives other than
+// module, go, require and replace are ignored.
+func ParseGoMod(data []byte) (*GoMod, error) {
+	m := new(GoMod)
+	block := ""
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		fields, err := modFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %v", i+1, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+
+		switch verb {
+		case "module":
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("%d: usage: module path", i+1)
+			}
+			m.Module = fields[0]
+		case "go":
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("%d: usage: go 1.23", i+1)
+			}
+			m.Go = fields[0]
+		case "require":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("%d: usage: require module/path v1.2.3", i+1)
+			}
+			m.Require = append(m.Require, ModuleVersion{fields[0], fields[1]})
+		case "replace":
+			var r ModuleReplace
+			switch {
+			case len(fields) == 3 && fields[1] == "=>":
+				r.Old = ModuleVersion{Path: fields[0]}
+				r.New = ModuleVersion{Path: fields[2]}
+			case len(fields) == 4 && fields[1] == "=>":
+				r.Old = ModuleVersion{Path: fields[0]}
+				r.New = ModuleVersion{fields[2], fields[3]}
+			case len(fields) == 4 && fields[2] == "=>":
+				r.Old = ModuleVersion{fields[0], fields[1]}
+				r.New = ModuleVersion{Path: fields[3]}
+			case len(fields) == 5 && fields[2] == "=>":
+				r.Old = ModuleVersion{fields[0], fields[1]}
+				r.New = ModuleVersion{fields[3], fields[4]}
+			default:
+				return nil, fmt.Errorf("%d: usage: replace module/path [v1.2.3] => other/module v1.4.5 or local/dir", i+1)
+			}
+			m.Replace = append(m.Replace, r)
+		}
+	}
+	if block != "" {
+		return nil, fmt.Errorf("%d: unterminated %s block", len(lines), block)
+	}
+	return m, nil
+}
+
+// modFields splits a go.mod line into its tokens, unquoting quoted strings
+// and dropping comments.
+func modFields(line string) ([]string, error) {
+	var fields []string
+	for {
+		line = strings.TrimLeft(line, " \t\r")
+		switch {
+		case line == "" || strings.HasPrefix(line, "//"):
+			return fields, nil
+		case line[0] == '"' || line[0] == '`':
+			prefix, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string %s", line)
+			}
+			s, _ := strconv.Unquote(prefix)
+			fields = append(fields, s)
+			line = line[len(prefix):]
+		default:
+			i := strings.IndexAny(line, " \t\r")
+			if i < 0 {
+				i = len(line)
+			}
+			fields = append(fields, line[:i])
+			line = line[i:]
+		}
+	}
+}
+
+// Replacement returns the module or local directory replacing mod, and
+// whether there is one. A replacement of a specific version takes
+// precedence over one of all versions.
+func (m *GoMod) Replacement(mod ModuleVersion) (ModuleVersion, bool) {
+	var (
+		found ModuleVersion
+		ok    bool
+	)
+	for _, r := range m.Replace {
+		if r.Old.Path != mod.Path {
+			continue
+		}
+		if r.Old.Version == mod.Version {
+			return r.New, true
+		}
+		if r.Old.Version == "" {
+			found, ok = r.New, true
+		}
+	}
+	return found, ok
+}
+
+// Locked returns the required modules as locked dependencies, following
+// their replacements. Tags are taken from the versions, and abbreviated
+// revisions from the pseudo-versions. A module replaced by a local directory
+// keeps its required version, unless it is the zero pseudo-version of an
+// unpublished module.
+func (m *GoMod) Locked() []LockedDependency {
+	var deps []LockedDependency
+	for _, r := range m.Require {
+		l := LockedDependency{Importpath: r.Path}
+		version := r.Version
+		if n, ok := m.Replacement(r); ok && n.Version == "" {
+			l.Replace = n.Path
+		} else if ok {
+			if n.Path != r.Path {
+				l.Source = n.Path
+			}
+			version = n.Version
+		}
+		if rev := PseudoVersionRevision(version); rev != "" {
+			if strings.Trim(rev, "0") != "" {
+				l.Revision = rev
+			}
+		} else {
+			l.Version = strings.TrimSuffix(version, "+incompatible")
+		}
+		deps = append(deps, l)
+	}
+	return deps
+}
+
+var pseudoVersion = regexp.MustCompile(`(?:^v\d+\.0\.0-|[.-]0\.)\d{14}-([0-9a-f]{12})(?:\+incompatible)?$`)
+
+// PseudoVersionRevision returns the abbreviated revision of a
+// pseudo-version, like v0.0.0-20190101123456-abcdef123456, or "" if
+// version is not one.
+func PseudoVersionRevision(version string) string {
+	m := pseudoVersion.FindStringSubmatch(version)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// PseudoVersion returns the pseudo-version of a revision committed at t, for
+// a module of the major version, like v0.0.0-20190101123456-abcdef123456.
+func PseudoVersion(major int, t time.Time, revision string) string {
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	return fmt.Sprintf("v%d.0.0-%s-%s", major, t.UTC().Format("20060102150405"), revision)
+}
+
+var pathMajor = regexp.MustCompile(`(?:^gopkg\.in/.*\.v(\d+)|/v([2-9]|[1-9]\d+))$`)
+
+// PathMajor returns the major version of a module path ending with a
+// major version suffix, like example.com/mod/v2 or gopkg.in/yaml.v3, and
+// false if it has none.
+func PathMajor(path string) (int, bool) {
+	m := pathMajor.FindStringSubmatch(path)
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1] + m[2])
+	return n, true
+}
+
+// Format returns the content of the go.mod file.
+func (m *GoMod) Format() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "module %s\n", quoteModPath(m.Module))
+	if m.Go != "" {
+		fmt.Fprintf(&buf, "\ngo %s\n", m.Go)
+	}
+	if len(m.Require) > 0 {
+		buf.WriteString("\nrequire (\n")
+		for _, r := range m.Require {
+			fmt.Fprintf(&buf, "\t%s %s\n", quoteModPath(r.Path), r.Version)
+		}
+		buf.WriteString(")\n")
+	}
+	if len(m.Replace) > 0 {
+		buf.WriteString("\nreplace (\n")
+		for _, r := range m.Replace {
+			buf.WriteString("\t" + quoteModPath(r.Old.Path))
+			if r.Old.Version != "" {
+				buf.WriteString(" " + r.Old.Version)
+			}
+			buf.WriteString(" => " + quoteModPath(r.New.Path))
+			if r.New.Version != "" {
+				buf.WriteString(" " + r.New.Version)
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(")\n")
+	}
+	return buf.Bytes()
+}
+
+// quoteModPath quotes paths which would not parse back as a single token,
+// like local directories holding spaces.
+func quoteModPath(path string) string {
+	if path == "" || strings.ContainsAny(path, " \t\"`\\") {
+		return strconv.Quote(path)
+	}
+	return path
+}
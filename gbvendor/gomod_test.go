@@ -0,0 +1,123 @@
+package vendor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testGoMod = `module example.com/project
+
+go 1.16
+
+require (
+	github.com/pkg/errors v0.9.1
+	golang.org/x/sys v0.0.0-20210124154548-22da62e12c0c // indirect
+	github.com/old/lib v2.0.0+incompatible
+	"example.com/quoted" v1.0.0
+	example.com/local v0.0.0-00010101000000-000000000000
+)
+
+require github.com/fork/me v1.2.0
+
+replace github.com/fork/me => github.com/mine/me v1.2.1
+
+replace (
+	example.com/quoted v1.0.0 => ../quoted
+	example.com/quoted => ./unused
+	example.com/local => ./local
+)
+`
+
+func TestParseGoMod(t *testing.T) {
+	m, err := ParseGoMod([]byte(testGoMod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Module != "example.com/project" {
+		t.Errorf("Module: got %q", m.Module)
+	}
+	if len(m.Require) != 6 || m.Require[3] != (ModuleVersion{"example.com/quoted", "v1.0.0"}) {
+		t.Errorf("Require: got %v", m.Require)
+	}
+	if len(m.Replace) != 4 {
+		t.Errorf("Replace: got %v", m.Replace)
+	}
+
+	want := []LockedDependency{
+		{Importpath: "github.com/pkg/errors", Version: "v0.9.1"},
+		{Importpath: "golang.org/x/sys", Revision: "22da62e12c0c"},
+		{Importpath: "github.com/old/lib", Version: "v2.0.0"},
+		{Importpath: "example.com/quoted", Version: "v1.0.0", Replace: "../quoted"},
+		{Importpath: "example.com/local", Replace: "./local"},
+		{Importpath: "github.com/fork/me", Source: "github.com/mine/me", Version: "v1.2.1"},
+	}
+	if got := m.Locked(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Locked: got %+v, want %+v", got, want)
+	}
+
+	for _, s := range []string{"require (\n\tfoo v1\n", "replace foo => \n", "module\n"} {
+		if _, err := ParseGoMod([]byte(s)); err == nil {
+			t.Errorf("ParseGoMod(%q): expected an error", s)
+		}
+	}
+}
+
+func TestPseudoVersionRevision(t *testing.T) {
+	tests := map[string]string{
+		"v0.0.0-20210124154548-22da62e12c0c":                "22da62e12c0c",
+		"v1.2.4-0.20210124154548-22da62e12c0c":              "22da62e12c0c",
+		"v1.3.0-beta.0.20210124154548-22da62e12c0c":         "22da62e12c0c",
+		"v2.0.1-0.20210124154548-22da62e12c0c+incompatible": "22da62e12c0c",
+		"v1.2.3":      "",
+		"v1.2.3-beta": "",
+	}
+	for version, want := range tests {
+		if got := PseudoVersionRevision(version); got != want {
+			t.Errorf("PseudoVersionRevision(%q): got %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestGoModFormat(t *testing.T) {
+	m := &GoMod{
+		Module:  "example.com/project",
+		Go:      "1.16",
+		Require: []ModuleVersion{{"github.com/pkg/errors", "v0.9.1"}, {"golang.org/x/net", "v0.0.0-20210124154548-22da62e12c0c"}},
+		Replace: []ModuleReplace{
+			{ModuleVersion{Path: "golang.org/x/net"}, ModuleVersion{"github.com/golang/net", "v0.0.0-20210124154548-22da62e12c0c"}},
+			{ModuleVersion{"github.com/pkg/errors", "v0.9.1"}, ModuleVersion{Path: "../my errors"}},
+		},
+	}
+	got, err := ParseGoMod(m.Format())
+	if err != nil {
+		t.Fatalf("%v\n%s", err, m.Format())
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("got %+v, want %+v", got, m)
+	}
+}
+
+func TestPathMajor(t *testing.T) {
+	tests := []struct {
+		path  string
+		major int
+		ok    bool
+	}{
+		{"github.com/pkg/errors", 0, false},
+		{"github.com/go-redis/redis/v8", 8, true},
+		{"github.com/a/b/v1", 0, false},
+		{"github.com/a/b/v10", 10, true},
+		{"gopkg.in/yaml.v2", 2, true},
+		{"gopkg.in/check.v1", 1, true},
+	}
+	for _, tt := range tests {
+		if major, ok := PathMajor(tt.path); major != tt.major || ok != tt.ok {
+			t.Errorf("PathMajor(%q): got %d, %v", tt.path, major, ok)
+		}
+	}
+	date := time.Date(2021, 1, 24, 16, 45, 48, 0, time.FixedZone("", 3600))
+	if got := PseudoVersion(2, date, "22da62e12c0c9a1e3b2f6f4ab1f1f4f3a0e4c5d6"); got != "v2.0.0-20210124154548-22da62e12c0c" {
+		t.Errorf("PseudoVersion: got %q", got)
+	}
+}
@@ -19,8 +19,13 @@ import (
 // ParseImports parses Go packages from a specific root returning a set of import paths.
 // vendorRoot is how deep to go looking for vendor folders, usually the repo root.
 // vendorPrefix is the vendorRoot import path.
-func ParseImports(root, vendorRoot, vendorPrefix string, tests, all bool) (map[string]bool, error) {
+// tags selects the files which are parsed, nil parses them all.
+//
+// Files which cannot be parsed are skipped, and reported by a ParseErrors
+// error returned along with the imports of the other files.
+func ParseImports(root, vendorRoot, vendorPrefix string, tests, all bool, tags *BuildTags) (map[string]bool, error) {
 	pkgs := make(map[string]bool)
+	var errs ParseErrors
 
 	var walkFn = func(p string, info os.FileInfo, err error) error {
 		// Lilx，如果有的文件 parse 出错，比如 bad.go，则继续
@@ -39,32 +44,87 @@ func ParseImports(root, vendorRoot, vendorPrefix string, tests, all bool) (map[s
 			return nil
 		}
 
-		fs := token.NewFileSet()
-		f, err := parser.ParseFile(fs, p, nil, parser.ImportsOnly)
+		if err := parseFileImports(p, vendorRoot, vendorPrefix, tags, pkgs); err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	}
 
-		if err != nil {
+	if err := filepath.Walk(root, walkFn); err != nil {
+		return pkgs, err
+	}
+	if errs != nil {
+		return pkgs, errs
+	}
+	return pkgs, nil
+}
+
+// ParsePackageImports is like ParseImports, but only parses the Go files
+// directly in dir, that is the files of a single package.
+func ParsePackageImports(dir, vendorRoot, vendorPrefix string, tests bool, tags *BuildTags) (map[string]bool, error) {
+	pkgs := make(map[string]bool)
+	var errs ParseErrors
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range files {
+		p := filepath.Join(dir, info.Name())
+		if info.IsDir() || filepath.Ext(p) != ".go" || fileutils.ShouldSkip(p, info, tests, false) {
+			continue
+		}
+		if err := parseFileImports(p, vendorRoot, vendorPrefix, tags, pkgs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return pkgs, errs
+	}
+	return pkgs, nil
+}
+
+// ParseErrors lists the errors of the Go files which could not be parsed.
+type ParseErrors []error
+
+func (e ParseErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", e[0], len(e)-1)
+}
+
+// parseFileImports adds the imports of the Go file p to pkgs, unless the
+// file does not match tags.
+func parseFileImports(p, vendorRoot, vendorPrefix string, tags *BuildTags, pkgs map[string]bool) error {
+	if tags != nil {
+		if ok, err := tags.Match(p); err != nil || !ok {
 			return err
 		}
+	}
 
-		for _, s := range f.Imports {
-			pkg := strings.Replace(s.Path.Value, "\"", "", -1)
-			if strings.HasPrefix(pkg, "./") {
-				middle, err := filepath.Rel(vendorRoot, filepath.Dir(p))
-				if err != nil {
-					panic(err)
-				}
-				pkg = path.Join(vendorPrefix, middle, pkg)
-			}
-			if vp := findVendor(vendorRoot, filepath.Dir(p), pkg); vp != "" {
-				pkg = path.Join(vendorPrefix, vp)
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, p, nil, parser.ImportsOnly)
+
+	if err != nil {
+		return err
+	}
+
+	for _, s := range f.Imports {
+		pkg := strings.Replace(s.Path.Value, "\"", "", -1)
+		if strings.HasPrefix(pkg, "./") {
+			middle, err := filepath.Rel(vendorRoot, filepath.Dir(p))
+			if err != nil {
+				panic(err)
 			}
-			pkgs[pkg] = true
+			pkg = path.Join(vendorPrefix, middle, pkg)
 		}
-		return nil
+		if vp := findVendor(vendorRoot, filepath.Dir(p), pkg); vp != "" {
+			pkg = path.Join(vendorPrefix, vp)
+		}
+		pkgs[pkg] = true
 	}
-
-	err := filepath.Walk(root, walkFn)
-	return pkgs, err
+	return nil
 }
 
 // findVendor looks for pkgName in a vendor folder at start/vendor or deeper, stopping
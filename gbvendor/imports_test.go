@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/uk702/gvt/fileutils"
 )
 
 func TestFetchMetadata(t *testing.T) {
@@ -135,3 +138,59 @@ func TestParseMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePackageImports(t *testing.T) {
+	root := mktemp(t)
+	defer fileutils.RemoveAll(root)
+
+	files := map[string]string{
+		"a/a.go":                          "package a\nimport (\n\t\"fmt\"\n\t\"github.com/x/y\"\n)\n",
+		"a/a_test.go":                     "package a\nimport \"testing\"\n",
+		"a/sub/sub.go":                    "package sub\nimport \"os\"\n",
+		"vendor/github.com/x/y/y.go":      "package y\n",
+		"vendor/github.com/x/y/README.md": "not go",
+		"a/testdata/broken.go":            "not go",
+	}
+	writeFiles(t, root, files)
+
+	got, err := ParsePackageImports(filepath.Join(root, "a"), root, "example.com/p", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"fmt": true, "example.com/p/vendor/github.com/x/y": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePackageImports: want %v, got %v", want, got)
+	}
+
+	got, err = ParsePackageImports(filepath.Join(root, "a"), root, "example.com/p", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want["testing"] = true
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePackageImports with tests: want %v, got %v", want, got)
+	}
+}
+
+func TestParseImportsErrors(t *testing.T) {
+	root := mktemp(t)
+	defer fileutils.RemoveAll(root)
+
+	files := map[string]string{
+		"a.go":          "package a\nimport \"fmt\"\n",
+		"bad.go":        "package a\nimport (\n\t\"os\"\n",
+		"worse/b.go":    "not go",
+		"testdata/c.go": "not go either",
+	}
+	writeFiles(t, root, files)
+
+	got, err := ParseImports(root, root, "example.com/a", false, false, nil)
+	errs, ok := err.(ParseErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("ParseImports: want 2 parse errors, got %v", err)
+	}
+	want := map[string]bool{"fmt": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseImports: want %v, got %v", want, got)
+	}
+}
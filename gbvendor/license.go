@@ -0,0 +1,76 @@
+package vendor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// licenseTitles identify the licenses by a phrase of their text. When a
+// text holds several of them, the first one found wins, so that a license
+// mentioning another one, like the GPL pointing at the LGPL, is not
+// mistaken for it. Versioned licenses are completed by the version
+// following the phrase, in the same sentence.
+var licenseTitles = []struct {
+	phrase string
+	id     string
+}{
+	{"gnu affero general public license", "AGPL"},
+	{"gnu lesser general public license", "LGPL"},
+	{"gnu library general public license", "LGPL"},
+	{"gnu general public license", "GPL"},
+	{"mozilla public license", "MPL"},
+	{"apache license", "Apache"},
+	{"eclipse public license", "EPL"},
+	{"this is free and unencumbered software released into the public domain", "Unlicense"},
+	{"cc0 1.0 universal", "CC0-1.0"},
+	{"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted", "ISC"},
+	{"permission to use, copy, modify, and distribute this software for any purpose with or without fee is hereby granted", "ISC"},
+	{"permission is hereby granted, free of charge, to any person obtaining a copy", "MIT"},
+	{"redistribution and use in source and binary forms, with or without modification, are permitted", "BSD"},
+}
+
+var (
+	licenseSpace   = regexp.MustCompile(`(?:\s|//+|[*#>])+`)
+	licenseVersion = regexp.MustCompile(`^[^.]{0,80}?\b(?:version|v\.?) ?(\d+(?:\.\d+)?)`)
+)
+
+// DetectLicense classifies a license text, returning its SPDX identifier,
+// like "MIT", "BSD-3-Clause" or "Apache-2.0", or "" if it is not recognized.
+func DetectLicense(text string) string {
+	// ignore case, line breaks and comment markers
+	text = strings.TrimSpace(licenseSpace.ReplaceAllString(strings.ToLower(text), " "))
+
+	var (
+		id    string
+		first = -1
+		rest  string
+	)
+	for _, t := range licenseTitles {
+		i := strings.Index(text, t.phrase)
+		if i >= 0 && (first < 0 || i < first) {
+			id, first, rest = t.id, i, text[i+len(t.phrase):]
+		}
+	}
+
+	switch id {
+	case "BSD":
+		switch {
+		case strings.Contains(rest, "all advertising materials"):
+			return "BSD-4-Clause"
+		case strings.Contains(rest, "endorse or promote products"):
+			return "BSD-3-Clause"
+		}
+		return "BSD-2-Clause"
+	case "AGPL", "LGPL", "GPL", "MPL", "Apache", "EPL":
+		m := licenseVersion.FindStringSubmatch(rest)
+		if m == nil {
+			return ""
+		}
+		v := m[1]
+		if !strings.Contains(v, ".") {
+			v += ".0"
+		}
+		return id + "-" + v
+	}
+	return id
+}
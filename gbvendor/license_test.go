@@ -0,0 +1,81 @@
+package vendor
+
+import "testing"
+
+func TestDetectLicense(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{{
+		text: `The MIT License (MIT)
+
+Copyright (c) 2014 Someone
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal`,
+		want: "MIT",
+	}, {
+		text: `Copyright (c) 2009 The Go Authors. All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are
+met:
+...
+   * Neither the name of Google Inc. nor the names of its
+contributors may be used to endorse or promote products derived from`,
+		want: "BSD-3-Clause",
+	}, {
+		text: `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+1. Redistributions of source code must retain the above copyright notice`,
+		want: "BSD-2-Clause",
+	}, {
+		text: `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`,
+		want: "Apache-2.0",
+	}, {
+		text: `Mozilla Public License Version 2.0
+==================================`,
+		want: "MPL-2.0",
+	}, {
+		text: `                    GNU GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007
+...
+the library.  If this is what you want to do, use the GNU Lesser General
+Public License instead of this License.`,
+		want: "GPL-3.0",
+	}, {
+		text: `		    GNU GENERAL PUBLIC LICENSE
+		       Version 2, June 1991`,
+		want: "GPL-2.0",
+	}, {
+		text: `                  GNU LESSER GENERAL PUBLIC LICENSE
+                       Version 2.1, February 1999`,
+		want: "LGPL-2.1",
+	}, {
+		text: `// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or`,
+		want: "AGPL-3.0",
+	}, {
+		text: `Permission to use, copy, modify, and/or distribute this software for any
+purpose with or without fee is hereby granted, provided that the above`,
+		want: "ISC",
+	}, {
+		text: `This is free and unencumbered software released into the public domain.`,
+		want: "Unlicense",
+	}, {
+		text: `All rights reserved. Do not copy.`,
+		want: "",
+	}, {
+		text: `GNU General Public License, see the website for the version.`,
+		want: "",
+	}}
+	for i, tt := range tests {
+		if got := DetectLicense(tt.text); got != tt.want {
+			t.Errorf("%d: DetectLicense: want %q, got %q", i, tt.want, got)
+		}
+	}
+}
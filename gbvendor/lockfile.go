@@ -0,0 +1,260 @@
+package vendor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LockedDependency is a dependency pinned by the manifest of another
+// vendoring tool.
+type LockedDependency struct {
+	// Importpath is the import path of the dependency, either a package
+	// or the root of its repository.
+	Importpath string
+
+	// Source is where the dependency is taken from instead of its import
+	// path, either another import path or a repository URL.
+	Source string
+
+	// VCS is the DVCS system of Source, if known.
+	VCS string
+
+	// Revision is the pinned revision. It may be abbreviated, or empty
+	// if Version is set.
+	Revision string
+
+	// Version is the tag of the revision, if any.
+	Version string
+
+	// Branch is the branch of the revision, if known.
+	Branch string
+
+	// Replace is a local directory holding the dependency.
+	Replace string
+}
+
+// LockFiles are the manifests of other vendoring tools, by format, in the
+// order they are looked for by FindLockFile. The names are relative to the
+// project root.
+var LockFiles = []struct {
+	Format string
+	Name   string
+}{
+	{"godep", "Godeps/Godeps.json"},
+	{"glide", "glide.lock"},
+	{"govendor", "vendor/vendor.json"},
+	{"dep", "Gopkg.lock"},
+	{"gomod", "go.mod"},
+}
+
+// FindLockFile returns the format and path of the first lock file found in
+// dir, or empty strings if there is none.
+func FindLockFile(dir string) (format, path string) {
+	for _, l := range LockFiles {
+		p := filepath.Join(dir, filepath.FromSlash(l.Name))
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return l.Format, p
+		}
+	}
+	return "", ""
+}
+
+// ReadLockFile reads the dependencies pinned by the lock file at path, in
+// one of the formats of LockFiles.
+func ReadLockFile(format, path string) ([]LockedDependency, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var deps []LockedDependency
+	switch format {
+	case "godep":
+		deps, err = parseGodeps(b)
+	case "glide":
+		deps, err = parseGlideLock(b)
+	case "govendor":
+		deps, err = parseGovendor(b)
+	case "dep":
+		deps, err = parseDepLock(b)
+	case "gomod":
+		var m *GoMod
+		if m, err = ParseGoMod(b); err == nil {
+			deps = m.Locked()
+		}
+	default:
+		return nil, fmt.Errorf("unknown lock file format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return deps, nil
+}
+
+// describeSuffix matches the suffix added by git describe to a tag when
+// the revision is not tagged itself.
+var describeSuffix = regexp.MustCompile(`-\d+-g[0-9a-f]+$`)
+
+// parseGodeps parses a Godeps/Godeps.json file, which pins packages.
+func parseGodeps(b []byte) ([]LockedDependency, error) {
+	var godeps struct {
+		Deps []struct {
+			ImportPath string
+			Comment    string // output of git describe
+			Rev        string
+		}
+	}
+	if err := json.Unmarshal(b, &godeps); err != nil {
+		return nil, err
+	}
+	var deps []LockedDependency
+	for _, d := range godeps.Deps {
+		l := LockedDependency{Importpath: d.ImportPath, Revision: d.Rev}
+		if !describeSuffix.MatchString(d.Comment) {
+			l.Version = d.Comment
+		}
+		deps = append(deps, l)
+	}
+	return deps, nil
+}
+
+// parseGovendor parses a vendor/vendor.json file, which pins packages.
+func parseGovendor(b []byte) ([]LockedDependency, error) {
+	var govendor struct {
+		Package []struct {
+			Path         string `json:"path"`
+			Origin       string `json:"origin"`
+			Revision     string `json:"revision"`
+			VersionExact string `json:"versionExact"`
+		} `json:"package"`
+	}
+	if err := json.Unmarshal(b, &govendor); err != nil {
+		return nil, err
+	}
+	var deps []LockedDependency
+	for _, p := range govendor.Package {
+		deps = append(deps, LockedDependency{
+			Importpath: p.Path,
+			Source:     p.Origin,
+			Revision:   p.Revision,
+			Version:    p.VersionExact,
+		})
+	}
+	return deps, nil
+}
+
+// parseGlideLock parses the imports and testImports of a glide.lock file.
+// Only the subset of YAML written by glide is supported.
+func parseGlideLock(b []byte) ([]LockedDependency, error) {
+	var (
+		deps    []LockedDependency
+		section string
+		current *LockedDependency
+	)
+	for i, line := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			// a top level key
+			section = strings.TrimSpace(strings.SplitN(line, ":", 2)[0])
+			current = nil
+			continue
+		}
+		if section != "imports" && section != "testImports" {
+			continue
+		}
+
+		text := strings.TrimSpace(line)
+		item := strings.HasPrefix(text, "- ")
+		text = strings.TrimSpace(strings.TrimPrefix(text, "- "))
+		kv := strings.SplitN(text, ":", 2)
+		if len(kv) != 2 {
+			// a subpackage
+			continue
+		}
+		key, value := kv[0], strings.TrimSpace(kv[1])
+		if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+			if len(value) < 2 || value[len(value)-1] != value[0] {
+				return nil, fmt.Errorf("%d: invalid quoted string %s", i+1, value)
+			}
+			value = value[1 : len(value)-1]
+		}
+		if item && key == "name" {
+			deps = append(deps, LockedDependency{Importpath: value})
+			current = &deps[len(deps)-1]
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("%d: expected a name", i+1)
+		}
+		switch key {
+		case "version":
+			current.Revision = value
+		case "repo":
+			current.Source = value
+		case "vcs":
+			current.VCS = value
+		}
+	}
+	return deps, nil
+}
+
+// parseDepLock parses the projects of a Gopkg.lock file. Only the subset
+// of TOML written by dep is supported.
+func parseDepLock(b []byte) ([]LockedDependency, error) {
+	var (
+		deps    []LockedDependency
+		current *LockedDependency
+	)
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case line == "[[projects]]":
+			deps = append(deps, LockedDependency{})
+			current = &deps[len(deps)-1]
+			continue
+		case strings.HasPrefix(line, "["):
+			current = nil
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if current == nil || len(kv) != 2 {
+			// another table, or an array on several lines
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if !strings.HasPrefix(value, `"`) {
+			continue
+		}
+		value, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("%d: invalid string %s", i+1, kv[1])
+		}
+		switch key {
+		case "name":
+			current.Importpath = value
+		case "source":
+			current.Source = value
+		case "revision":
+			current.Revision = value
+		case "version":
+			current.Version = value
+		case "branch":
+			current.Branch = value
+		}
+	}
+	for _, d := range deps {
+		if d.Importpath == "" {
+			return nil, fmt.Errorf("project without a name")
+		}
+	}
+	return deps, nil
+}
@@ -0,0 +1,138 @@
+package vendor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uk702/gvt/fileutils"
+)
+
+func TestReadLockFile(t *testing.T) {
+	tests := []struct {
+		format  string
+		content string
+		want    []LockedDependency
+	}{{
+		"godep",
+		`{
+	"ImportPath": "example.com/project",
+	"Deps": [
+		{"ImportPath": "github.com/pkg/errors", "Comment": "v0.8.0", "Rev": "645ef00459ed84a119197bfb8d8205042c6df63d"},
+		{"ImportPath": "golang.org/x/net/context", "Comment": "v0.1-12-gabcdef0", "Rev": "f2499483f923065a842d38eb4c7f1927e6fc6e6d"}
+	]
+}`,
+		[]LockedDependency{
+			{Importpath: "github.com/pkg/errors", Revision: "645ef00459ed84a119197bfb8d8205042c6df63d", Version: "v0.8.0"},
+			{Importpath: "golang.org/x/net/context", Revision: "f2499483f923065a842d38eb4c7f1927e6fc6e6d"},
+		},
+	}, {
+		"glide",
+		`hash: 1234
+updated: 2017-01-01T00:00:00Z
+imports:
+- name: github.com/pkg/errors
+  version: 645ef00459ed84a119197bfb8d8205042c6df63d
+- name: golang.org/x/net
+  version: f2499483f923065a842d38eb4c7f1927e6fc6e6d
+  repo: "https://github.com/golang/net"
+  vcs: git
+  subpackages:
+  - context
+  - http2
+testImports:
+- name: github.com/stretchr/testify
+  version: 69483b4bd14f5845b5a1e55bca19e954e827f1d0
+`,
+		[]LockedDependency{
+			{Importpath: "github.com/pkg/errors", Revision: "645ef00459ed84a119197bfb8d8205042c6df63d"},
+			{Importpath: "golang.org/x/net", Source: "https://github.com/golang/net", VCS: "git", Revision: "f2499483f923065a842d38eb4c7f1927e6fc6e6d"},
+			{Importpath: "github.com/stretchr/testify", Revision: "69483b4bd14f5845b5a1e55bca19e954e827f1d0"},
+		},
+	}, {
+		"govendor",
+		`{
+	"rootPath": "example.com/project",
+	"package": [
+		{"path": "github.com/pkg/errors", "revision": "645ef00459ed84a119197bfb8d8205042c6df63d", "version": "v0.8", "versionExact": "v0.8.0"},
+		{"path": "golang.org/x/net/context", "origin": "github.com/golang/net/context", "revision": "f2499483f923065a842d38eb4c7f1927e6fc6e6d"}
+	]
+}`,
+		[]LockedDependency{
+			{Importpath: "github.com/pkg/errors", Revision: "645ef00459ed84a119197bfb8d8205042c6df63d", Version: "v0.8.0"},
+			{Importpath: "golang.org/x/net/context", Source: "github.com/golang/net/context", Revision: "f2499483f923065a842d38eb4c7f1927e6fc6e6d"},
+		},
+	}, {
+		"dep",
+		`# This file is autogenerated, do not edit; changes may be undone by the next 'dep ensure'.
+
+
+[[projects]]
+  digest = "1:abc"
+  name = "github.com/pkg/errors"
+  packages = ["."]
+  pruneopts = "UT"
+  revision = "645ef00459ed84a119197bfb8d8205042c6df63d"
+  version = "v0.8.0"
+
+[[projects]]
+  branch = "master"
+  name = "golang.org/x/net"
+  packages = [
+    "context",
+    "http2",
+  ]
+  revision = "f2499483f923065a842d38eb4c7f1927e6fc6e6d"
+  source = "https://github.com/golang/net"
+
+[solve-meta]
+  analyzer-name = "dep"
+  input-imports = ["github.com/pkg/errors"]
+`,
+		[]LockedDependency{
+			{Importpath: "github.com/pkg/errors", Revision: "645ef00459ed84a119197bfb8d8205042c6df63d", Version: "v0.8.0"},
+			{Importpath: "golang.org/x/net", Source: "https://github.com/golang/net", Revision: "f2499483f923065a842d38eb4c7f1927e6fc6e6d", Branch: "master"},
+		},
+	}}
+
+	dir := mktemp(t)
+	defer fileutils.RemoveAll(dir)
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.format)
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadLockFile(tt.format, path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFindLockFile(t *testing.T) {
+	dir := mktemp(t)
+	defer fileutils.RemoveAll(dir)
+
+	if format, _ := FindLockFile(dir); format != "" {
+		t.Errorf("empty folder: got %q", format)
+	}
+	for _, name := range []string{"go.mod", "Godeps/Godeps.json"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	format, path := FindLockFile(dir)
+	if format != "godep" || path != filepath.Join(dir, "Godeps", "Godeps.json") {
+		t.Errorf("got %q, %q", format, path)
+	}
+}
@@ -20,6 +20,10 @@ type Manifest struct {
 	// Manifest version. Current manifest version is 0.
 	Version int `json:"version"`
 
+	// BuildTags selects the files parsed for imports, see ParseBuildTags.
+	// All files are parsed when it is empty.
+	BuildTags string `json:"buildtags,omitempty"`
+
 	// Depenencies is a list of vendored dependencies.
 	Dependencies []Dependency `json:"dependencies"`
 }
@@ -107,6 +111,14 @@ type Dependency struct {
 	// Can be blank if not needed.
 	Branch string `json:"branch"`
 
+	// Tag is the tag the Revision was fetched from.
+	// Can be blank if not needed.
+	Tag string `json:"tag,omitempty"`
+
+	// Constraint is the semantic version range the Tag is
+	// chosen from on update, like "^1.4".
+	Constraint string `json:"constraint,omitempty"`
+
 	// Path is the path inside the Repository where the
 	// dependency was fetched from.
 	Path string `json:"path,omitempty"`
@@ -117,6 +129,36 @@ type Dependency struct {
 
 	// AllFiles indicates that no files were ignored.
 	AllFiles bool `json:"allfiles,omitempty"`
+
+	// Patches are the local patches applied, in order, on top of
+	// the fetched source. They are relative to the vendor folder.
+	Patches []string `json:"patches,omitempty"`
+
+	// Replace is a local directory the dependency is taken from
+	// instead of Repository. Relative paths are relative to the
+	// folder containing the vendor folder.
+	Replace string `json:"replace,omitempty"`
+
+	// Symlink indicates that the vendor folder links to Replace
+	// instead of holding a copy of it.
+	Symlink bool `json:"symlink,omitempty"`
+
+	// Parent is the dependency whose imports caused this one to be
+	// fetched. It is empty for dependencies fetched explicitly or for
+	// the project itself.
+	Parent string `json:"parent,omitempty"`
+
+	// License is the SPDX identifier of the license of the dependency,
+	// as detected from its license files.
+	License string `json:"license,omitempty"`
+
+	// Module is the path of the Go module holding the dependency, as
+	// declared by its go.mod file.
+	Module string `json:"module,omitempty"`
+
+	// ModuleVersion is the version of Module, when it was fetched at a
+	// version required by another module or at a semantic version tag.
+	ModuleVersion string `json:"moduleversion,omitempty"`
 }
 
 // WriteManifest writes a Manifest to the path. If the manifest does
@@ -2,6 +2,7 @@ package vendor
 
 import (
 	"bytes"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -17,6 +18,20 @@ func mktemp(t *testing.T) string {
 	return s
 }
 
+// writeFiles creates the files below dir, by slash separated path, with
+// their content.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func assertNotExists(t *testing.T, path string) {
 	_, err := os.Stat(path)
 	if err == nil || !os.IsNotExist(err) {
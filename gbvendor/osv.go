@@ -0,0 +1,268 @@
+package vendor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Advisory is a security advisory in the OSV format, see
+// https://ossf.github.io/osv-schema/.
+type Advisory struct {
+	ID        string            `json:"id"`
+	Summary   string            `json:"summary"`
+	Details   string            `json:"details"`
+	Aliases   []string          `json:"aliases"`
+	Withdrawn string            `json:"withdrawn"`
+	Affected  []AffectedPackage `json:"affected"`
+}
+
+// AffectedPackage is a package affected by an advisory, and its affected
+// versions.
+type AffectedPackage struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges            []AffectedRange `json:"ranges"`
+	Versions          []string        `json:"versions"`
+	EcosystemSpecific struct {
+		Imports []struct {
+			Path string `json:"path"`
+		} `json:"imports"`
+	} `json:"ecosystem_specific"`
+}
+
+// AffectedRange is a range of affected versions. The versions are semantic
+// versions for the SEMVER type, and commits for the GIT type.
+type AffectedRange struct {
+	Type   string       `json:"type"`
+	Repo   string       `json:"repo"`
+	Events []RangeEvent `json:"events"`
+}
+
+// RangeEvent is a bound of an AffectedRange, only one field is set.
+type RangeEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// ReadAdvisories reads the advisories of an OSV database, either a JSON file
+// holding one advisory or a list of them, or a folder of such files.
+// Withdrawn advisories are skipped.
+func ReadAdvisories(path string) ([]Advisory, error) {
+	var advisories []Advisory
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || p != path && filepath.Ext(p) != ".json" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var list []Advisory
+		if err := json.Unmarshal(b, &list); err != nil {
+			var a Advisory
+			if err := json.Unmarshal(b, &a); err != nil {
+				return fmt.Errorf("%s: %v", p, err)
+			}
+			list = []Advisory{a}
+		}
+		for _, a := range list {
+			if a.ID != "" && a.Withdrawn == "" {
+				advisories = append(advisories, a)
+			}
+		}
+		return nil
+	})
+	return advisories, err
+}
+
+// Exposure tells whether a dependency is affected by an advisory.
+type Exposure int
+
+const (
+	NotAffected Exposure = iota
+
+	// PossiblyAffected means that the package is affected, but the version
+	// of the dependency cannot be compared to the affected versions.
+	PossiblyAffected
+
+	Affected
+)
+
+// Matches reports whether the affected package, or one of the packages it
+// lists, is importpath, a package below it or a parent of it.
+func (p *AffectedPackage) Matches(importpath string) bool {
+	if p.Package.Ecosystem != "" && p.Package.Ecosystem != "Go" {
+		return false
+	}
+	paths := []string{p.Package.Name}
+	for _, i := range p.EcosystemSpecific.Imports {
+		paths = append(paths, i.Path)
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if path == importpath || strings.HasPrefix(path, importpath+"/") || strings.HasPrefix(importpath, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Check tells whether the dependency at version, which may be empty, and
+// revision is affected. Semantic version ranges and versions are compared
+// to version, and git ranges to the revision. Git ranges are evaluated with
+// the ancestry of the revision in the history h, if not nil, or else only
+// decide when the revision is one of their bounds.
+func (p *AffectedPackage) Check(version, revision string, h History) Exposure {
+	v, err := ParseVersion(version)
+	tagged := version != "" && err == nil
+
+	if tagged {
+		for _, s := range p.Versions {
+			if w, err := ParseVersion(s); err == nil && w.Compare(v) == 0 {
+				return Affected
+			}
+		}
+	}
+
+	decided := tagged && len(p.Versions) > 0
+	for _, r := range p.Ranges {
+		switch r.Type {
+		case "SEMVER", "ECOSYSTEM":
+			if !tagged {
+				continue
+			}
+			decided = true
+			if r.affects(v) {
+				return Affected
+			}
+		case "GIT":
+			if revision == "" {
+				continue
+			}
+			affected, ok := r.affectsRevision(revision, h)
+			decided = decided || ok
+			if affected {
+				return Affected
+			}
+		}
+	}
+	if decided {
+		return NotAffected
+	}
+	return PossiblyAffected
+}
+
+// affectsRevision evaluates a git range, and reports whether it could. The
+// revision is affected if it descends from an introduced commit, and neither
+// from a fixed commit nor strictly from a last affected one.
+func (r *AffectedRange) affectsRevision(revision string, h History) (affected, ok bool) {
+	for _, e := range r.Events {
+		switch revision {
+		case e.Introduced, e.LastAffected:
+			return true, true
+		case e.Fixed:
+			return false, true
+		}
+	}
+	if h == nil {
+		return false, false
+	}
+
+	// descends reports whether revision is commit or one of its descendants
+	descends := func(commit string) (bool, error) {
+		commits, err := h.Log(revision, commit)
+		return err == nil && len(commits) == 0, err
+	}
+	var introduced, fixed bool
+	for _, e := range r.Events {
+		var (
+			d   bool
+			err error
+		)
+		switch {
+		case e.Introduced == "0":
+			introduced = true
+		case e.Introduced != "":
+			d, err = descends(e.Introduced)
+			introduced = introduced || d
+		case e.Fixed != "":
+			d, err = descends(e.Fixed)
+			fixed = fixed || d
+		case e.LastAffected != "":
+			d, err = descends(e.LastAffected)
+			fixed = fixed || d
+		}
+		if err != nil {
+			// a commit missing from the repository, like one of a fork
+			return false, false
+		}
+	}
+	return introduced && !fixed, true
+}
+
+// affects evaluates a semantic version range, following the OSV rules.
+func (r *AffectedRange) affects(v Version) bool {
+	type event struct {
+		v    Version
+		kind string
+	}
+	var events []event
+	for _, e := range r.Events {
+		kind, s := "introduced", e.Introduced
+		switch {
+		case e.Fixed != "":
+			kind, s = "fixed", e.Fixed
+		case e.LastAffected != "":
+			kind, s = "last_affected", e.LastAffected
+		}
+		w, err := ParseVersion(s)
+		if err != nil {
+			continue
+		}
+		events = append(events, event{w, kind})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].v.Compare(events[j].v) < 0 })
+
+	affected := false
+	for _, e := range events {
+		n := v.Compare(e.v)
+		switch {
+		case e.kind == "introduced" && n >= 0:
+			affected = true
+		case e.kind == "fixed" && n >= 0:
+			affected = false
+		case e.kind == "last_affected" && n > 0:
+			affected = false
+		}
+	}
+	return affected
+}
+
+// FixedVersions returns the versions fixing the advisory, for display.
+func (p *AffectedPackage) FixedVersions() []string {
+	var fixed []string
+	for _, r := range p.Ranges {
+		if r.Type == "GIT" {
+			continue
+		}
+		for _, e := range r.Events {
+			if e.Fixed != "" {
+				fixed = append(fixed, e.Fixed)
+			}
+		}
+	}
+	return fixed
+}
@@ -0,0 +1,154 @@
+package vendor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uk702/gvt/fileutils"
+)
+
+const testAdvisory = `{
+	"id": "GO-2020-0001",
+	"summary": "Arbitrary log line injection",
+	"affected": [{
+		"package": {"ecosystem": "Go", "name": "github.com/gin-gonic/gin"},
+		"ranges": [
+			{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.6.0"}, {"introduced": "1.7.0"}, {"last_affected": "1.7.2"}]},
+			{"type": "GIT", "repo": "https://github.com/gin-gonic/gin", "events": [{"introduced": "aaaa"}, {"fixed": "ffff"}]}
+		],
+		"ecosystem_specific": {"imports": [{"path": "github.com/gin-gonic/gin/render"}]}
+	}]
+}`
+
+func TestReadAdvisories(t *testing.T) {
+	dir := mktemp(t)
+	defer fileutils.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.json":     testAdvisory,
+		"sub/b.json": `[{"id": "GO-2", "affected": []}, {"id": "GO-3", "withdrawn": "2021-01-01T00:00:00Z"}]`,
+		"README":     "not json",
+	}
+	writeFiles(t, dir, files)
+
+	advisories, err := ReadAdvisories(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(advisories) != 2 || advisories[0].ID != "GO-2020-0001" || advisories[1].ID != "GO-2" {
+		t.Errorf("ReadAdvisories: got %+v", advisories)
+	}
+
+	if advisories, err = ReadAdvisories(filepath.Join(dir, "a.json")); err != nil || len(advisories) != 1 {
+		t.Errorf("ReadAdvisories of a file: got %v advisories, %v", len(advisories), err)
+	}
+}
+
+func TestAffectedPackage(t *testing.T) {
+	path := writeAdvisory(t)
+	defer os.Remove(path)
+	advisories, err := ReadAdvisories(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &advisories[0].Affected[0]
+
+	for path, want := range map[string]bool{
+		"github.com/gin-gonic/gin":         true,
+		"github.com/gin-gonic/gin/binding": true,
+		"github.com/gin-gonic":             true,
+		"github.com/gin-gonic/gin-contrib": false,
+	} {
+		if got := p.Matches(path); got != want {
+			t.Errorf("Matches(%q): want %v, got %v", path, want, got)
+		}
+	}
+
+	tests := []struct {
+		tag, revision string
+		want          Exposure
+	}{
+		{"v1.5.9", "1234", Affected},
+		{"v1.6.0", "1234", NotAffected},
+		{"v1.7.2", "1234", Affected},
+		{"v1.7.3", "1234", NotAffected},
+		{"", "aaaa", Affected},
+		{"", "ffff", NotAffected},
+		{"", "1234", PossiblyAffected},
+		{"release", "1234", PossiblyAffected},
+	}
+	for _, tt := range tests {
+		if got := p.Check(tt.tag, tt.revision, nil); got != tt.want {
+			t.Errorf("Check(%q, %q): want %v, got %v", tt.tag, tt.revision, tt.want, got)
+		}
+	}
+
+	// a linear history from 0000 to gggg
+	h := linearHistory{"0000", "aaaa", "bbbb", "ffff", "gggg"}
+	for revision, want := range map[string]Exposure{
+		"0000": NotAffected,
+		"aaaa": Affected,
+		"bbbb": Affected,
+		"ffff": NotAffected,
+		"gggg": NotAffected,
+		"1234": PossiblyAffected,
+	} {
+		if got := p.Check("", revision, h); got != want {
+			t.Errorf("Check(\"\", %q) with history: want %v, got %v", revision, want, got)
+		}
+	}
+}
+
+// linearHistory is the History of a repository without merges, oldest
+// commit first.
+type linearHistory []string
+
+func (h linearHistory) index(rev string) (int, error) {
+	for i, r := range h {
+		if r == rev {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown revision %s", rev)
+}
+
+func (h linearHistory) Resolve(ref string) (string, error) {
+	_, err := h.index(ref)
+	return ref, err
+}
+
+func (h linearHistory) Commit(rev string) (Commit, error) {
+	_, err := h.index(rev)
+	return Commit{Revision: rev}, err
+}
+
+func (h linearHistory) Log(from, to string) ([]Commit, error) {
+	i, err := h.index(from)
+	if err != nil {
+		return nil, err
+	}
+	j, err := h.index(to)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for ; j > i; j-- {
+		commits = append(commits, Commit{Revision: h[j]})
+	}
+	return commits, nil
+}
+
+func writeAdvisory(t *testing.T) string {
+	f, err := ioutil.TempFile("", "osv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(testAdvisory); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
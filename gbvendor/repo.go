@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/uk702/gvt/fileutils"
 )
@@ -29,6 +30,9 @@ type RemoteRepo interface {
 
 	// Type returns the repository type (git, hg, ...)
 	Type() string
+
+	// Tags returns the tags of the remote repository.
+	Tags() ([]string, error)
 }
 
 // WorkingCopy represents a local copy of a remote dvcs repository.
@@ -47,6 +51,30 @@ type WorkingCopy interface {
 	Destroy() error
 }
 
+// Commit describes a commit of a repository.
+type Commit struct {
+	Revision string    `json:"revision"`
+	Date     time.Time `json:"date"`
+	Subject  string    `json:"subject"`
+}
+
+// History is implemented by working copies that can inspect the history of
+// their repository. It requires a full clone, as made by Checkout when a
+// revision is supplied.
+type History interface {
+
+	// Resolve returns the revision a branch, tag or revision refers to.
+	// An empty ref refers to the default branch.
+	Resolve(ref string) (string, error)
+
+	// Commit returns the commit of a revision.
+	Commit(rev string) (Commit, error)
+
+	// Log returns the commits reachable from to but not from from,
+	// newest first.
+	Log(from, to string) ([]Commit, error)
+}
+
 var (
 	ghregex   = regexp.MustCompile(`^(?P<root>github\.com/([A-Za-z0-9_.\-]+/[A-Za-z0-9_.\-]+))(/[A-Za-z0-9_.\-]+)*$`)
 	bbregex   = regexp.MustCompile(`^(?P<root>bitbucket\.org/(?P<bitname>[A-Za-z0-9_.\-]+/[A-Za-z0-9_.\-]+))(/[A-Za-z0-9_.\-]+)*$`)
@@ -184,6 +212,31 @@ func DeduceRemoteRepo(path string, insecure bool) (RemoteRepo, string, error) {
 	}
 }
 
+// ForkRemoteRepo returns the repository of a fork of the repository of
+// importpath, and the path of the package inside it. A repoURL with a scheme
+// is taken as the root of the fork, anything else is resolved like an import
+// path. When repoURL is the root of the fork, the package is at the same path
+// as in the repository of importpath.
+func ForkRemoteRepo(repoURL, importpath string, insecure bool) (RemoteRepo, string, error) {
+	var (
+		repo  RemoteRepo
+		extra string
+		err   error
+	)
+	if u, perr := url.Parse(repoURL); perr == nil && u.Scheme != "" {
+		repo, err = NewRemoteRepo(repoURL, "", insecure)
+	} else {
+		repo, extra, err = DeduceRemoteRepo(repoURL, insecure)
+	}
+	if err != nil || extra != "" {
+		return repo, extra, err
+	}
+	if _, extra, err = DeduceRemoteRepo(importpath, insecure); err != nil {
+		return nil, "", fmt.Errorf("could not find the path of %s in its repository: %v", importpath, err)
+	}
+	return repo, extra, nil
+}
+
 func NewRemoteRepo(repoURL, vcs string, insecure bool) (RemoteRepo, error) {
 	u, err := url.Parse(repoURL)
 	if err != nil {
@@ -308,12 +361,38 @@ func (g *gitrepo) Type() string {
 	return "git"
 }
 
+func (g *gitrepo) Tags() ([]string, error) {
+	out, err := run("git", "ls-remote", "--tags", g.url)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitTags(out), nil
+}
+
+// parseGitTags returns the tag names in the output of git ls-remote --tags.
+func parseGitTags(out []byte) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+		tag := strings.TrimSuffix(strings.TrimPrefix(fields[1], "refs/tags/"), "^{}")
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 // Checkout fetchs the remote branch, tag, or revision. If the branch is blank,
 // then the default remote branch will be used. If the branch is "HEAD" and
 // revision is empty, an impossible update is assumed.
 func (g *gitrepo) Checkout(branch, tag, revision string, verbose bool) (WorkingCopy, error) {
 	if branch == "HEAD" && revision == "" {
-		return nil, fmt.Errorf("cannot update %q as it has been previously fetched with -tag or -revision. Please use gvt delete then fetch again.", g.url)
+		return nil, fmt.Errorf("cannot update %q as it has been previously fetched with -tag or -revision. Please use gvt update with -branch, -tag or -revision.", g.url)
 	}
 	if !atMostOne(tag, revision) {
 		return nil, fmt.Errorf("only one of tag or revision may be supplied")
@@ -392,6 +471,63 @@ func (g *GitClone) Branch() (string, error) {
 	return strings.TrimSpace(string(rev)), err
 }
 
+func (g *GitClone) Resolve(ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// branches only exist as remote branches in a clone
+	for _, r := range []string{"refs/remotes/origin/" + ref, ref} {
+		rev, err := runPath(g.path, "git", "rev-parse", "--verify", "--quiet", r+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(string(rev)), nil
+		}
+	}
+	return "", fmt.Errorf("unknown revision %q", ref)
+}
+
+// gitLogFormat is the git log format parsed by parseLog.
+const gitLogFormat = "--format=%H%x09%cI%x09%s"
+
+func (g *GitClone) Commit(rev string) (Commit, error) {
+	out, err := runPath(g.path, "git", "log", "-1", gitLogFormat, rev)
+	if err != nil {
+		return Commit{}, err
+	}
+	commits, err := parseLog(out)
+	if err != nil || len(commits) != 1 {
+		return Commit{}, fmt.Errorf("unknown revision %q", rev)
+	}
+	return commits[0], nil
+}
+
+func (g *GitClone) Log(from, to string) ([]Commit, error) {
+	out, err := runPath(g.path, "git", "log", gitLogFormat, from+".."+to)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out)
+}
+
+// parseLog parses lines of tab separated revision, RFC 3339 date and subject.
+func parseLog(out []byte) ([]Commit, error) {
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected log line: %q", line)
+		}
+		date, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, Commit{Revision: fields[0], Date: date, Subject: fields[2]})
+	}
+	return commits, nil
+}
+
 // Hgrepo returns a RemoteRepo representing a remote git repository.
 func Hgrepo(u *url.URL, insecure bool, schemes ...string) (RemoteRepo, error) {
 	if len(schemes) == 0 {
@@ -416,6 +552,28 @@ type hgrepo struct {
 func (h *hgrepo) URL() string  { return h.url }
 func (h *hgrepo) Type() string { return "hg" }
 
+func (h *hgrepo) Tags() ([]string, error) {
+	dir, err := mktmp()
+	if err != nil {
+		return nil, err
+	}
+	defer fileutils.RemoveAll(dir)
+	if err := runQuiet("hg", "clone", "--noupdate", "--noninteractive", h.url, dir); err != nil {
+		return nil, err
+	}
+	out, err := run("hg", "--cwd", dir, "tags", "--quiet")
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, tag := range strings.Split(string(out), "\n") {
+		if tag = strings.TrimSpace(tag); tag != "" && tag != "tip" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
 func (h *hgrepo) Checkout(branch, tag, revision string, verbose bool) (WorkingCopy, error) {
 	if !atMostOne(tag, revision) {
 		return nil, fmt.Errorf("only one of tag or revision may be supplied")
@@ -438,8 +596,8 @@ func (h *hgrepo) Checkout(branch, tag, revision string, verbose bool) (WorkingCo
 		fileutils.RemoveAll(dir)
 		return nil, err
 	}
-	if revision != "" {
-		if err := runOut(os.Stderr, "hg", "--cwd", dir, "update", "-r", revision); err != nil {
+	if rev := oneOf(revision, tag); rev != "" {
+		if err := runOut(os.Stderr, "hg", "--cwd", dir, "update", "-r", rev); err != nil {
 			fileutils.RemoveAll(dir)
 			return nil, err
 		}
@@ -467,6 +625,41 @@ func (h *HgClone) Branch() (string, error) {
 	return strings.TrimSpace(string(rev)), err
 }
 
+func (h *HgClone) Resolve(ref string) (string, error) {
+	if ref == "" {
+		ref = "default"
+	}
+	rev, err := run("hg", "--cwd", h.path, "log", "-r", ref, "--template", "{node}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q", ref)
+	}
+	return strings.TrimSpace(string(rev)), nil
+}
+
+// hgLogTemplate is the hg log template parsed by parseLog.
+const hgLogTemplate = "{node}\t{date|rfc3339date}\t{desc|firstline}\n"
+
+func (h *HgClone) Commit(rev string) (Commit, error) {
+	out, err := run("hg", "--cwd", h.path, "log", "-r", rev, "--template", hgLogTemplate)
+	if err != nil {
+		return Commit{}, err
+	}
+	commits, err := parseLog(out)
+	if err != nil || len(commits) != 1 {
+		return Commit{}, fmt.Errorf("unknown revision %q", rev)
+	}
+	return commits[0], nil
+}
+
+func (h *HgClone) Log(from, to string) ([]Commit, error) {
+	revset := fmt.Sprintf("reverse(only(%q, %q))", to, from)
+	out, err := run("hg", "--cwd", h.path, "log", "-r", revset, "--template", hgLogTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out)
+}
+
 // Bzrrepo returns a RemoteRepo representing a remote bzr repository.
 func Bzrrepo(url string) (RemoteRepo, error) {
 	if err := probeBzrUrl(url); err != nil {
@@ -492,6 +685,20 @@ func (b *bzrrepo) Type() string {
 	return "bzr"
 }
 
+func (b *bzrrepo) Tags() ([]string, error) {
+	out, err := run("bzr", "tags", "-d", b.url)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			tags = append(tags, fields[0])
+		}
+	}
+	return tags, nil
+}
+
 func (b *bzrrepo) Checkout(branch, tag, revision string, verbose bool) (WorkingCopy, error) {
 	if !atMostOne(tag, revision) {
 		return nil, fmt.Errorf("only one of tag or revision may be supplied")
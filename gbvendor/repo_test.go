@@ -153,3 +153,60 @@ func TestDeduceRemoteRepo(t *testing.T) {
 		}
 	}
 }
+
+func TestForkRemoteRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skipf("skipping network tests in -short mode")
+	}
+	// github.com/pkg/errors stands for a fork of github.com/pkg/sftp
+	tests := []struct {
+		repoURL, importpath string
+		want, extra         string
+	}{
+		{"https://github.com/pkg/errors", "github.com/pkg/sftp/examples/gsftp", "https://github.com/pkg/errors", "/examples/gsftp"},
+		{"https://github.com/pkg/errors", "github.com/pkg/sftp", "https://github.com/pkg/errors", ""},
+		{"github.com/pkg/errors", "github.com/pkg/sftp/examples/gsftp", "https://github.com/pkg/errors", "/examples/gsftp"},
+		{"github.com/pkg/sftp/examples", "github.com/pkg/sftp/examples/gsftp", "https://github.com/pkg/sftp", "/examples"},
+	}
+	for _, tt := range tests {
+		got, extra, err := ForkRemoteRepo(tt.repoURL, tt.importpath, false)
+		if err != nil {
+			t.Errorf("ForkRemoteRepo(%q, %q): %v", tt.repoURL, tt.importpath, err)
+			continue
+		}
+		if got.URL() != tt.want || extra != tt.extra {
+			t.Errorf("ForkRemoteRepo(%q, %q): want %s, %q, got %s, %q", tt.repoURL, tt.importpath, tt.want, tt.extra, got.URL(), extra)
+		}
+	}
+}
+
+func TestParseGitTags(t *testing.T) {
+	out := []byte(`7d5e1a0f4c1a8c6bd2de3a4f8f0e3e5c2a1b0c9d	refs/tags/v1.0.0
+2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e	refs/tags/v1.1.0
+8f9e0d1c2b3a49586776859403a2b1c0d9e8f7a6	refs/tags/v1.1.0^{}
+`)
+	want := []string{"v1.0.0", "v1.1.0"}
+	if got := parseGitTags(out); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseGitTags: want %v, got %v", want, got)
+	}
+}
+
+func TestParseLog(t *testing.T) {
+	out := []byte("8f9e0d1c\t2017-03-14T10:20:30+08:00\tFix the build\n2b3c4d5e\t2017-03-13T09:00:00Z\tAdd\ttabs\n")
+	commits, err := parseLog(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("parseLog: want 2 commits, got %d", len(commits))
+	}
+	if c := commits[0]; c.Revision != "8f9e0d1c" || c.Subject != "Fix the build" || c.Date.Unix() != 1489458030 {
+		t.Errorf("parseLog: unexpected first commit %+v", c)
+	}
+	if c := commits[1]; c.Subject != "Add\ttabs" {
+		t.Errorf("parseLog: want subject %q, got %q", "Add\ttabs", c.Subject)
+	}
+	if commits, err := parseLog(nil); err != nil || len(commits) != 0 {
+		t.Errorf("parseLog(nil): want no commits, got %v, %v", commits, err)
+	}
+}
@@ -0,0 +1,305 @@
+package vendor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version, as found in release tags like v1.2.3.
+type Version struct {
+	Major, Minor, Patch int
+
+	// Pre is the pre-release part of the version, without the dash.
+	Pre string
+}
+
+// ParseVersion parses a semantic version. A leading "v" is accepted,
+// a missing minor or patch number is taken as 0 and build metadata is
+// ignored.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	str := strings.TrimPrefix(s, "v")
+	if i := strings.Index(str, "+"); i >= 0 {
+		str = str[:i]
+	}
+	if i := strings.Index(str, "-"); i >= 0 {
+		v.Pre = str[i+1:]
+		str = str[:i]
+		if v.Pre == "" {
+			return v, fmt.Errorf("%q is not a semantic version", s)
+		}
+	}
+	parts := strings.Split(str, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("%q is not a semantic version", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || p != strconv.Itoa(n) {
+			return v, fmt.Errorf("%q is not a semantic version", s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on whether v sorts before, with or
+// after w, following the semantic versioning precedence rules.
+func (v Version) Compare(w Version) int {
+	switch {
+	case v.Major != w.Major:
+		return cmpInt(v.Major, w.Major)
+	case v.Minor != w.Minor:
+		return cmpInt(v.Minor, w.Minor)
+	case v.Patch != w.Patch:
+		return cmpInt(v.Patch, w.Patch)
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.Pre, "."), strings.Split(w.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		x, errx := strconv.Atoi(a[i])
+		y, erry := strconv.Atoi(b[i])
+		switch {
+		case errx == nil && erry == nil:
+			return cmpInt(x, y)
+		case errx == nil:
+			return -1
+		case erry == nil:
+			return 1
+		case a[i] < b[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return cmpInt(len(a), len(b))
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// NewerTag returns the newest tag of the same major version as the current
+// tag, if it is newer than current. Otherwise it returns current. Moving to
+// another major version, which may break the importing code, is left to
+// explicit constraints. It returns false if current is not a semantic
+// version, to which the tags cannot be compared.
+func NewerTag(tags []string, current string) (string, bool) {
+	v, err := ParseVersion(current)
+	if err != nil {
+		return current, false
+	}
+	newer := func(w Version) bool { return w.Major == v.Major && w.Compare(v) > 0 }
+	if latest, ok := LatestVersion(tags, newer); ok {
+		return latest, true
+	}
+	return current, true
+}
+
+// LatestVersion returns the tag with the highest semantic version for which
+// match returns true. Tags that are not semantic versions and pre-releases
+// are ignored. A nil match accepts any version.
+func LatestVersion(tags []string, match func(Version) bool) (string, bool) {
+	var (
+		latest  string
+		version Version
+		found   bool
+	)
+	for _, tag := range tags {
+		v, err := ParseVersion(tag)
+		if err != nil || v.Pre != "" {
+			continue
+		}
+		if match != nil && !match(v) {
+			continue
+		}
+		if !found || v.Compare(version) > 0 {
+			latest, version, found = tag, v, true
+		}
+	}
+	return latest, found
+}
+
+// Constraint is a set of semantic version ranges, like "^1.4", "~1.2.3" or
+// ">=1.2, <1.5 || 2.x".
+type Constraint struct {
+	s      string
+	ranges [][]comparison // any range matches when all its comparisons do
+}
+
+type comparison struct {
+	op string
+	v  Version
+}
+
+func (c comparison) match(v Version) bool {
+	n := v.Compare(c.v)
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	}
+	return n == 0
+}
+
+// ParseConstraint parses a version constraint. Ranges are separated by "||",
+// and the comparisons of a range by commas or spaces. The supported
+// comparisons are "=", "<", "<=", ">", ">=", caret ranges like "^1.4"
+// (>=1.4.0, <2.0.0), tilde ranges like "~1.2" (>=1.2.0, <1.3.0), and partial
+// versions like "1.2" or "1.2.x" (>=1.2.0, <1.3.0). "*" matches any version,
+// an empty range is an error.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{s: s}
+	for _, r := range strings.Split(opSpace.ReplaceAllString(s, "$1"), "||") {
+		var cmps []comparison
+		terms := strings.FieldsFunc(r, func(r rune) bool { return r == ',' || r == ' ' })
+		if len(terms) == 0 {
+			// would match any version, "*" has to be explicit
+			return c, fmt.Errorf("invalid constraint %q: empty range", s)
+		}
+		for _, term := range terms {
+			t, err := parseComparison(term)
+			if err != nil {
+				return c, fmt.Errorf("invalid constraint %q: %v", s, err)
+			}
+			cmps = append(cmps, t...)
+		}
+		c.ranges = append(c.ranges, cmps)
+	}
+	return c, nil
+}
+
+// opSpace matches the spaces allowed between an operator and its version.
+var opSpace = regexp.MustCompile(`([<>=^~]+)\s+`)
+
+func parseComparison(term string) ([]comparison, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, o) {
+			op, term = o, strings.TrimSpace(term[len(o):])
+			break
+		}
+	}
+	if term == "*" || term == "x" || term == "X" {
+		if op != "" && op != "=" && op != ">=" {
+			return nil, fmt.Errorf("%q cannot be used with any version", op)
+		}
+		return nil, nil
+	}
+
+	// a partial version is a version with missing or wildcard components
+	parts := strings.Split(strings.TrimPrefix(term, "v"), ".")
+	n := len(parts)
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			n = i
+			parts = parts[:i]
+			break
+		}
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%q is not a version", term)
+	}
+	v, err := ParseVersion(strings.Join(parts, "."))
+	if err != nil {
+		return nil, err
+	}
+	if n < 3 && v.Pre != "" {
+		return nil, fmt.Errorf("%q is not a version", term)
+	}
+
+	// next returns the lowest version after all the versions v matches
+	// when only its first i components are significant.
+	next := func(i int) Version {
+		switch i {
+		case 1:
+			return Version{Major: v.Major + 1}
+		case 2:
+			return Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+
+	switch op {
+	case "^":
+		// the first non zero component is significant
+		switch {
+		case v.Major != 0 || n == 1:
+			return []comparison{{">=", v}, {"<", next(1)}}, nil
+		case v.Minor != 0 || n == 2:
+			return []comparison{{">=", v}, {"<", next(2)}}, nil
+		}
+		return []comparison{{">=", v}, {"<", next(3)}}, nil
+	case "~":
+		if n == 1 {
+			return []comparison{{">=", v}, {"<", next(1)}}, nil
+		}
+		return []comparison{{">=", v}, {"<", next(2)}}, nil
+	case ">":
+		if n < 3 {
+			return []comparison{{">=", next(n)}}, nil
+		}
+	case "<=":
+		if n < 3 {
+			return []comparison{{"<", next(n)}}, nil
+		}
+	case "", "=":
+		if n < 3 {
+			return []comparison{{">=", v}, {"<", next(n)}}, nil
+		}
+		op = "="
+	}
+	return []comparison{{op, v}}, nil
+}
+
+// Match reports whether v satisfies the constraint.
+func (c Constraint) Match(v Version) bool {
+	for _, r := range c.ranges {
+		ok := true
+		for _, cmp := range r {
+			if !cmp.match(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Constraint) String() string {
+	return c.s
+}
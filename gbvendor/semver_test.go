@@ -0,0 +1,140 @@
+package vendor
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Version
+		err  bool
+	}{
+		{s: "v1.2.3", want: Version{1, 2, 3, ""}},
+		{s: "1.2", want: Version{1, 2, 0, ""}},
+		{s: "v2", want: Version{2, 0, 0, ""}},
+		{s: "v1.0.0-rc.1+build.5", want: Version{1, 0, 0, "rc.1"}},
+		{s: "v1.02.3", err: true},
+		{s: "v1.2.3.4", err: true},
+		{s: "v1.2.3-", err: true},
+		{s: "release-1", err: true},
+		{s: "", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.s)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseVersion(%q): unexpected error: %v", tt.s, err)
+			continue
+		}
+		if !tt.err && got != tt.want {
+			t.Errorf("ParseVersion(%q): want %v, got %v", tt.s, tt.want, got)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// in ascending order
+	versions := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.2.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := range versions {
+		for j := range versions {
+			a, _ := ParseVersion(versions[i])
+			b, _ := ParseVersion(versions[j])
+			if got, want := a.Compare(b), cmpInt(i, j); got != want {
+				t.Errorf("Compare(%s, %s): want %d, got %d", versions[i], versions[j], want, got)
+			}
+		}
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	tags := []string{"v1.2.0", "v1.10.0", "v2.0.0-rc.1", "latest", "v1.9.3"}
+	if got, ok := LatestVersion(tags, nil); !ok || got != "v1.10.0" {
+		t.Errorf("LatestVersion: want v1.10.0, got %q", got)
+	}
+	below := func(v Version) bool { return v.Minor < 5 }
+	if got, ok := LatestVersion(tags, below); !ok || got != "v1.2.0" {
+		t.Errorf("LatestVersion: want v1.2.0, got %q", got)
+	}
+	if got, ok := LatestVersion([]string{"latest"}, nil); ok {
+		t.Errorf("LatestVersion: want no match, got %q", got)
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		c     string
+		match []string
+		miss  []string
+	}{
+		{c: "^1.4", match: []string{"1.4.0", "1.9.2"}, miss: []string{"1.3.9", "2.0.0"}},
+		{c: "^0.4", match: []string{"0.4.0", "0.4.7"}, miss: []string{"0.5.0", "0.3.0"}},
+		{c: "^0.0.3", match: []string{"0.0.3"}, miss: []string{"0.0.4"}},
+		{c: "~1.2", match: []string{"1.2.0", "1.2.9"}, miss: []string{"1.3.0", "1.1.9"}},
+		{c: "~1.2.3", match: []string{"1.2.3", "1.2.4"}, miss: []string{"1.2.2", "1.3.0"}},
+		{c: "1.2", match: []string{"1.2.0", "1.2.5"}, miss: []string{"1.3.0"}},
+		{c: "1.2.x", match: []string{"1.2.5"}, miss: []string{"1.3.0"}},
+		{c: "=1.2.3", match: []string{"1.2.3"}, miss: []string{"1.2.4"}},
+		{c: ">1.2", match: []string{"1.3.0"}, miss: []string{"1.2.9"}},
+		{c: "<=1.2", match: []string{"1.2.9"}, miss: []string{"1.3.0"}},
+		{c: ">= 1.2 < 1.5", match: []string{"1.2.0"}, miss: []string{"1.5.0"}},
+		{c: ">=1.2, <1.5", match: []string{"1.2.0", "1.4.9"}, miss: []string{"1.5.0", "1.1.0"}},
+		{c: "<1.0 || >=2.1", match: []string{"0.9.0", "2.1.0"}, miss: []string{"1.0.0", "2.0.5"}},
+		{c: "*", match: []string{"0.0.1", "3.0.0"}},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.c)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q): %v", tt.c, err)
+			continue
+		}
+		for _, s := range tt.match {
+			if v, _ := ParseVersion(s); !c.Match(v) {
+				t.Errorf("%q should match %s", tt.c, s)
+			}
+		}
+		for _, s := range tt.miss {
+			if v, _ := ParseVersion(s); c.Match(v) {
+				t.Errorf("%q should not match %s", tt.c, s)
+			}
+		}
+	}
+
+	for _, s := range []string{"^", "~foo", "1.2-rc", "<*", "", ">=1.0 ||", "|| <2", "1.x || , || 2.x"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q): expected an error", s)
+		}
+	}
+}
+
+func TestNewerTag(t *testing.T) {
+	tags := []string{"v1.2.0", "v1.9.0", "v1.10.0-rc1", "v2.0.0", "v3.1.0", "latest"}
+	tests := map[string]string{
+		"v1.2.0": "v1.9.0",
+		"v1.9.0": "v1.9.0",
+		"v2.0.0": "v2.0.0",
+		"v0.1.0": "v0.1.0",
+		"3.0.0":  "v3.1.0",
+	}
+	for current, want := range tests {
+		got, ok := NewerTag(tags, current)
+		if !ok || got != want {
+			t.Errorf("NewerTag(%q): got %q, %v, want %q", current, got, ok, want)
+		}
+	}
+	for _, current := range []string{"latest", "release-2019"} {
+		if got, ok := NewerTag(tags, current); ok || got != current {
+			t.Errorf("NewerTag(%q): got %q, %v, want %q, false", current, got, ok, current)
+		}
+	}
+}
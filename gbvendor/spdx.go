@@ -0,0 +1,28 @@
+package vendor
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// spdxInvalid matches the characters not allowed in SPDX identifiers.
+var spdxInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// SPDXIDs returns unique SPDX identifiers for the elements named names, made
+// of prefix and the name. Characters not allowed in identifiers are replaced
+// by hyphens, and identifiers which would collide with a previous one get a
+// -2, -3... suffix.
+func SPDXIDs(prefix string, names []string) []string {
+	seen := make(map[string]bool)
+	ids := make([]string, len(names))
+	for i, name := range names {
+		id := prefix + spdxInvalid.ReplaceAllString(name, "-")
+		unique := id
+		for n := 2; seen[unique]; n++ {
+			unique = fmt.Sprintf("%s-%d", id, n)
+		}
+		seen[unique] = true
+		ids[i] = unique
+	}
+	return ids
+}
@@ -0,0 +1,20 @@
+package vendor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSPDXIDs(t *testing.T) {
+	names := []string{"example.com/project", "github.com/a-b/c", "github.com/a/b-c", "github.com/a/b/c", "github.com/a-b-c-2"}
+	want := []string{
+		"SPDXRef-Package-example.com-project",
+		"SPDXRef-Package-github.com-a-b-c",
+		"SPDXRef-Package-github.com-a-b-c-2",
+		"SPDXRef-Package-github.com-a-b-c-3",
+		"SPDXRef-Package-github.com-a-b-c-2-2",
+	}
+	if got := SPDXIDs("SPDXRef-Package-", names); !reflect.DeepEqual(got, want) {
+		t.Errorf("SPDXIDs: got %q, want %q", got, want)
+	}
+}
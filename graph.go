@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	graphFormat string // output format
+)
+
+func addGraphFlags(fs *flag.FlagSet) {
+	fs.StringVar(&graphFormat, "format", "tree", "output format: dot, json or tree")
+	addStrictFlag(fs)
+}
+
+var cmdGraph = &Command{
+	Name:      "graph",
+	UsageLine: "graph [-format dot|json|tree] [-strict] [importpath]",
+	Short:     "print the dependency graph",
+	Long: `graph prints the graph of the dependencies of the project.
+
+The graph is computed from the imports of the project packages, including
+their tests, followed through the vendor folder. Each node is a dependency of
+the manifest, or the project itself, and there is an edge from a node to
+another when one of its packages imports a package of the other.
+
+If an import path is supplied, only the part of the graph reachable from its
+dependency is printed.
+
+Flags:
+	-format
+		dot prints a graph for Graphviz, json the list of dependencies with
+		their imports, the packages importing them and the dependency they
+		were fetched for, and tree (the default) an indented tree.
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+`,
+	Run: func(args []string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("graph: more than one import path supplied")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		g, err := loadImportGraph(m)
+		if err != nil {
+			return fmt.Errorf("could not parse the imports of the project: %v", err)
+		}
+		nodes := dependencyGraph(m, g)
+
+		root := projectName()
+		if len(args) == 1 {
+			d, err := m.GetDependencyForImportpath(args[0])
+			if err != nil {
+				return fmt.Errorf("could not get dependency: %v", err)
+			}
+			root = d.Importpath
+		}
+
+		switch graphFormat {
+		case "dot":
+			return printGraphDot(os.Stdout, nodes, root)
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "\t")
+			return enc.Encode(reachableNodes(nodes, root))
+		case "tree":
+			printGraphTree(os.Stdout, nodes, root, 0, make(map[string]bool))
+			return nil
+		}
+		return fmt.Errorf("unknown format %q", graphFormat)
+	},
+	AddFlags: addGraphFlags,
+}
+
+// graphNode is a dependency, or the project, in the dependency graph.
+type graphNode struct {
+	Importpath string `json:"importpath"`
+
+	// Parent is the dependency recorded as the reason of the fetch.
+	Parent string `json:"parent,omitempty"`
+
+	// Imports are the dependencies imported by the packages of the node.
+	Imports []string `json:"imports,omitempty"`
+
+	// ImportedBy are the packages importing packages of the node.
+	ImportedBy []string `json:"importedby,omitempty"`
+}
+
+// projectName is the name of the project node in the dependency graph.
+func projectName() string {
+	if importPath == "" {
+		return "."
+	}
+	return importPath
+}
+
+// dependencyGraph collapses the packages of the import graph into their
+// dependencies, keyed by import path. All the dependencies of the manifest
+// are present, even when they are not imported.
+func dependencyGraph(m *vendor.Manifest, g *importGraph) map[string]*graphNode {
+	nodes := map[string]*graphNode{
+		projectName(): {Importpath: projectName()},
+	}
+	for _, d := range m.Dependencies {
+		nodes[d.Importpath] = &graphNode{Importpath: d.Importpath, Parent: d.Parent}
+	}
+
+	// owner returns the node of a package
+	owner := func(p string) string {
+		if g.project[p] {
+			return projectName()
+		}
+		if d, err := m.GetDependencyForImportpath(p); err == nil {
+			return d.Importpath
+		}
+		// vendored without being in the manifest
+		if nodes[p] == nil {
+			nodes[p] = &graphNode{Importpath: p}
+		}
+		return p
+	}
+
+	imports := make(map[string]map[string]bool)
+	importedBy := make(map[string]map[string]bool)
+	for p, deps := range g.imports {
+		from := owner(p)
+		for _, d := range deps {
+			if !g.vendored[d] {
+				continue
+			}
+			to := owner(d)
+			if to == from {
+				continue
+			}
+			if imports[from] == nil {
+				imports[from] = make(map[string]bool)
+			}
+			imports[from][to] = true
+			if importedBy[to] == nil {
+				importedBy[to] = make(map[string]bool)
+			}
+			importedBy[to][p] = true
+		}
+	}
+	for name, n := range nodes {
+		n.Imports = sortedKeys(imports[name])
+		n.ImportedBy = sortedKeys(importedBy[name])
+	}
+	return nodes
+}
+
+// reachableNodes returns the nodes reachable from root, sorted by import path.
+func reachableNodes(nodes map[string]*graphNode, root string) []*graphNode {
+	var (
+		reachable []*graphNode
+		seen      = make(map[string]bool)
+		queue     = []string{root}
+	)
+	if root == projectName() {
+		// list unused dependencies too
+		queue = nil
+		for name := range nodes {
+			queue = append(queue, name)
+		}
+	}
+	for _, name := range queue {
+		seen[name] = true
+	}
+	for len(queue) > 0 {
+		n := nodes[queue[0]]
+		queue = queue[1:]
+		reachable = append(reachable, n)
+		for _, i := range n.Imports {
+			if !seen[i] {
+				seen[i] = true
+				queue = append(queue, i)
+			}
+		}
+	}
+	sort.Slice(reachable, func(i, j int) bool {
+		return reachable[i].Importpath < reachable[j].Importpath
+	})
+	return reachable
+}
+
+func printGraphDot(w io.Writer, nodes map[string]*graphNode, root string) error {
+	fmt.Fprintln(w, "digraph dependencies {")
+	for _, n := range reachableNodes(nodes, root) {
+		fmt.Fprintf(w, "\t%q;\n", n.Importpath)
+		for _, i := range n.Imports {
+			fmt.Fprintf(w, "\t%q -> %q;\n", n.Importpath, i)
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// printGraphTree prints the dependencies imported by name as an indented
+// tree. Dependencies already printed are not expanded again.
+func printGraphTree(w io.Writer, nodes map[string]*graphNode, name string, level int, printed map[string]bool) {
+	n := nodes[name]
+	if printed[name] {
+		if len(n.Imports) > 0 {
+			name += " ..."
+		}
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", level), name)
+		return
+	}
+	printed[name] = true
+	fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", level), name)
+	for _, i := range n.Imports {
+		printGraphTree(w, nodes, i, level+1, printed)
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	importFrom    string // format of the manifest to import
+	importRestore bool   // restore the dependencies after importing them
+)
+
+func addImportFlags(fs *flag.FlagSet) {
+	fs.StringVar(&importFrom, "from", "", "format of the manifest: godep, glide, govendor, dep or gomod")
+	fs.BoolVar(&importRestore, "restore", false, "restore the dependencies after importing them")
+	fs.BoolVar(&rbInsecure, "precaire", false, "allow the use of insecure protocols")
+	fs.UintVar(&rbConnections, "connections", 8, "count of parallel download connections")
+}
+
+var cmdImport = &Command{
+	Name:      "import",
+	UsageLine: "import [-from godep|glide|govendor|dep|gomod] [-restore] [-precaire] [-connections N]",
+	Short:     "import dependencies from another vendoring tool",
+	Long: `import adds the dependencies pinned by the manifest of another vendoring tool
+to the manifest, to adopt a project managed by that tool.
+
+The manifest is read from the project folder:
+
+    godep       Godeps/Godeps.json
+    glide       glide.lock
+    govendor    vendor/vendor.json
+    dep         Gopkg.lock
+    gomod       go.mod
+
+Dependencies are vendored as their repositories, at the pinned revisions or
+tags. The repositories are deduced from the import paths, or from the sources
+recorded by the tool, like forks. A go.mod replacement by a local folder is
+recorded as with "gvt replace", on top of the required version. A module of
+major version 2 or more is taken from the vN subfolder of its repository if it
+holds the module, or else from the root of the repository, as with fetch.
+Dependencies already in the manifest are kept.
+
+Only the manifest is written, use -restore or "gvt restore" to fetch the code.
+
+Flags:
+	-from
+		the format of the manifest; by default the first one found is used,
+		in the order above.
+	-restore
+		restore the dependencies after importing them.
+	-precaire
+		allow the use of insecure protocols.
+	-connections
+		count of parallel download connections.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("import: unexpected arguments")
+		}
+
+		root := filepath.Dir(vendorDir)
+		format, path := importFrom, ""
+		if format == "" {
+			if format, path = vendor.FindLockFile(root); format == "" {
+				return fmt.Errorf("no manifest of another vendoring tool found")
+			}
+		} else {
+			for _, l := range vendor.LockFiles {
+				if l.Format == format {
+					path = filepath.Join(root, filepath.FromSlash(l.Name))
+				}
+			}
+			if path == "" {
+				return fmt.Errorf("unknown format %q", format)
+			}
+		}
+
+		locked, err := vendor.ReadLockFile(format, path)
+		if err != nil {
+			return err
+		}
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		// godep and govendor pin packages, vendor their repositories
+		packages := format == "godep" || format == "govendor"
+		imported := make(map[string]bool)
+		for _, l := range locked {
+			dep, err := importDependency(l, packages)
+			if err != nil {
+				return fmt.Errorf("could not import %s: %v", l.Importpath, err)
+			}
+			if imported[dep.Importpath] {
+				continue
+			}
+			imported[dep.Importpath] = true
+			if err := m.AddDependency(dep); err != nil {
+				log.Printf("skipping %s: %v", dep.Importpath, err)
+				continue
+			}
+			log.Printf("imported %s", dep.Importpath)
+		}
+
+		if err := vendor.WriteManifest(manifestFile, m); err != nil {
+			return err
+		}
+		if importRestore {
+			return restore(manifestFile)
+		}
+		return nil
+	},
+	AddFlags: addImportFlags,
+}
+
+// importDependency turns a dependency pinned by another tool into a manifest
+// dependency. If packages is true, the dependency is a package which is
+// widened to its repository. A dependency replaced by a local folder keeps
+// its upstream pin, if it has one which can be resolved.
+func importDependency(l vendor.LockedDependency, packages bool) (vendor.Dependency, error) {
+	local := vendor.Dependency{Importpath: l.Importpath, Replace: l.Replace}
+	if l.Replace != "" && l.Revision == "" && l.Version == "" {
+		// an unpublished module
+		return local, nil
+	}
+	dep, err := resolveDependency(l, packages)
+	if err != nil && l.Replace != "" {
+		log.Printf("%s: keeping only its replacement by %s: %v", l.Importpath, l.Replace, err)
+		return local, nil
+	}
+	dep.Replace = l.Replace
+	return dep, err
+}
+
+// resolveDependency resolves the repository and revision of a dependency
+// pinned by another tool.
+func resolveDependency(l vendor.LockedDependency, packages bool) (vendor.Dependency, error) {
+	source := l.Source
+	if source == "" {
+		source = l.Importpath
+	}
+	var (
+		repo  vendor.RemoteRepo
+		extra string
+		err   error
+	)
+	if u, perr := url.Parse(source); perr == nil && u.Scheme != "" {
+		repo, err = vendor.NewRemoteRepo(source, l.VCS, rbInsecure)
+	} else {
+		repo, extra, err = GlobalDownloader.DeduceRemoteRepo(source, rbInsecure)
+	}
+	if err != nil {
+		return vendor.Dependency{}, err
+	}
+
+	// a cached repository gives the package path without leading slash
+	extra = packagePath(extra)
+
+	importpath := l.Importpath
+	if packages {
+		// the path of the package inside the repository of its import path
+		sub := extra
+		if source != l.Importpath {
+			if _, sub, err = GlobalDownloader.DeduceRemoteRepo(l.Importpath, rbInsecure); err != nil {
+				return vendor.Dependency{}, err
+			}
+			sub = packagePath(sub)
+		}
+		if sub != "" && strings.HasSuffix(importpath, sub) {
+			importpath = strings.TrimSuffix(importpath, sub)
+			extra = strings.TrimSuffix(extra, sub)
+		}
+	}
+
+	revision, branch, tag := l.Revision, l.Branch, l.Version
+	var wc vendor.WorkingCopy
+	m := majorSuffix.FindStringSubmatch(extra)
+	switch {
+	case m != nil && m[3] == "" && (tag != "" || revision != ""):
+		// a module of major version 2 or more, like example.com/mod/v2, is
+		// either a subfolder of its repository or its root at v2 tags
+		pin := &upstreamPin{version: tag, revision: revision}
+		var t string
+		if wc, extra, t, err = checkoutModule(l.Importpath, repo, "", extra, pin, ""); err != nil {
+			return vendor.Dependency{}, err
+		}
+		if t != "" {
+			tag = t
+		}
+	case revision == "" || repo.Type() == "git" && len(revision) < 40:
+		// resolve tags and abbreviated revisions
+		t := tag
+		if revision != "" {
+			t = ""
+		}
+		if wc, err = GlobalDownloader.Get(repo, "", t, revision, false); err != nil {
+			return vendor.Dependency{}, err
+		}
+	}
+	if wc != nil {
+		if revision, err = wc.Revision(); err != nil {
+			return vendor.Dependency{}, err
+		}
+		if branch == "" {
+			if branch, err = wc.Branch(); err != nil {
+				return vendor.Dependency{}, err
+			}
+		}
+	}
+
+	return vendor.Dependency{
+		Importpath: importpath,
+		Repository: repo.URL(),
+		VCS:        repo.Type(),
+		Revision:   revision,
+		Branch:     branch,
+		Tag:        tag,
+		Path:       extra,
+		NoTests:    true,
+	}, nil
+}
+
+// packagePath returns the path of a package inside its repository in the
+// /path/to/pkg form, or "" for the repository root.
+func packagePath(extra string) string {
+	if extra = strings.Trim(extra, "/"); extra == "" {
+		return ""
+	}
+	return "/" + extra
+}
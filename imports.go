@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/uk702/gvt/fileutils"
+	"github.com/uk702/gvt/gbvendor"
+)
+
+// unvendor strips the project vendor folder from an import path resolved by
+// ParseImports on the project root.
+func unvendor(p string) string {
+	prefix := path.Join("/", importPath, "vendor") + "/"
+	if q := path.Join("/", filepath.ToSlash(p)); strings.HasPrefix(q, prefix) {
+		return strings.TrimPrefix(q, prefix)
+	}
+	return p
+}
+
+// importGraph is the import graph of the packages of the project and of the
+// vendored packages they use, directly or not.
+type importGraph struct {
+	// project holds the import paths of the project packages.
+	project map[string]bool
+
+	// vendored holds the import paths of the vendored packages reachable
+	// from the project.
+	vendored map[string]bool
+
+	// imports maps every package to the packages it imports. Vendored
+	// packages are known by their import path.
+	imports map[string][]string
+}
+
+// loadImportGraph parses the project packages, including their tests, and
+// follows their imports through the vendor folder. Tests of vendored packages
+// are not followed. Only the files matching the build tags of the manifest
+// are parsed.
+func loadImportGraph(m *vendor.Manifest) (*importGraph, error) {
+	buildTags, err := manifestBuildTags(m)
+	if err != nil {
+		return nil, err
+	}
+	g := &importGraph{
+		project:  make(map[string]bool),
+		vendored: make(map[string]bool),
+		imports:  make(map[string][]string),
+	}
+	root := filepath.Dir(vendorDir)
+
+	type pkg struct {
+		path, dir string
+		tests     bool
+	}
+	var queue []pkg
+
+	err = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if p == vendorDir || p != root && fileutils.ShouldSkip(p, info, false, false) {
+			return filepath.SkipDir
+		}
+		if !hasGoFiles(p) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := path.Join(importPath, filepath.ToSlash(rel))
+		if importPath != "" && rel == "." {
+			name = importPath
+		}
+		g.project[name] = true
+		queue = append(queue, pkg{name, p, true})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for len(queue) > 0 {
+		q := queue[0]
+		queue = queue[1:]
+
+		imports, err := vendor.ParsePackageImports(q.dir, root, importPath, q.tests, buildTags)
+		if err = tolerateParseErrors(err); err != nil {
+			return nil, err
+		}
+		var deps []string
+		for i := range imports {
+			d := unvendor(i)
+			deps = append(deps, d)
+			if d == i || g.vendored[d] {
+				continue
+			}
+			g.vendored[d] = true
+			queue = append(queue, pkg{d, filepath.Join(vendorDir, filepath.FromSlash(d)), false})
+		}
+		sort.Strings(deps)
+		g.imports[q.path] = deps
+	}
+	return g, nil
+}
+
+// hasGoFiles reports whether dir directly contains Go files.
+func hasGoFiles(dir string) bool {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, info := range files {
+		if !info.IsDir() && filepath.Ext(info.Name()) == ".go" {
+			return true
+		}
+	}
+	return false
+}
+
+// uses reports whether a reachable vendored package is p or below p.
+func (g *importGraph) uses(p string) bool {
+	for r := range g.vendored {
+		if contains(p, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// missing returns the sorted imports of the graph which are neither project
+// packages, vendored packages, part of the standard library nor ignored by
+// the import rules.
+func (g *importGraph) missing() []string {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, deps := range g.imports {
+		for _, d := range deps {
+			if seen[d] || g.project[d] || g.vendored[d] || ignoredImport(d) {
+				continue
+			}
+			seen[d] = true
+			missing = append(missing, d)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+var (
+	strict      bool     // fail on files which cannot be parsed
+	parseErrors []string // errors of the files skipped while parsing
+	parseSeen   = make(map[string]bool)
+)
+
+func addStrictFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&strict, "strict", false, "fail on Go files which cannot be parsed")
+}
+
+// tolerateParseErrors records the files which could not be parsed, unless
+// -strict is set, and returns the other errors.
+func tolerateParseErrors(err error) error {
+	errs, ok := err.(vendor.ParseErrors)
+	if !ok || strict {
+		return err
+	}
+	for _, e := range errs {
+		if !parseSeen[e.Error()] {
+			parseSeen[e.Error()] = true
+			parseErrors = append(parseErrors, e.Error())
+		}
+	}
+	return nil
+}
+
+// reportParseErrors prints the files skipped because they could not be parsed.
+func reportParseErrors() {
+	if len(parseErrors) == 0 {
+		return
+	}
+	log.Printf("%d Go files could not be parsed, their imports were ignored (use -strict to fail instead):", len(parseErrors))
+	for _, e := range parseErrors {
+		log.Printf("\t%s", e)
+	}
+}
@@ -20,6 +20,7 @@ func addInitFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&tests, "t", false, "fetch _test.go files and testdata")
 	fs.BoolVar(&all, "a", false, "fetch all files and subfolders")
 	fs.BoolVar(&verbose, "v", false, "verbose show checkout progress")
+	addStrictFlag(fs)
 }
 
 var cmdInit = &Command{
@@ -29,18 +30,25 @@ var cmdInit = &Command{
 	Long:      `sacn all source files and download all dependence`,
 	Run: func(args []string) error {
 		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
 		level := 0
 
 		path, _ := os.Getwd()
 		// dir, _ := ioutil.TempDir("", "gvt-")
-		deps, err := vendor.ParseImports(path, path, "", tests, all)
-
+		buildTags, err := manifestBuildTags(m)
 		if err != nil {
+			return err
+		}
+		deps, err := vendor.ParseImports(path, path, "", tests, all, buildTags)
+
+		if err = tolerateParseErrors(err); err != nil {
 			return fmt.Errorf("failed to parse imports: %s", err)
 		}
 
 		for d := range deps {
-			if strings.Index(d, ".") == -1 { // TODO: replace this silly heuristic
+			if !shouldFetch(d) {
 				continue
 			}
 
@@ -70,7 +78,7 @@ var cmdInit = &Command{
 				continue
 			}
 
-			if err := fetchRecursive(m, d, level+1); err != nil {
+			if err := fetchRecursive(m, d, "", level+1); err != nil {
 				if strings.HasPrefix(err.Error(), "error fetching") {
 					fmt.Println(err)
 				}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/uk702/gvt/fileutils"
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	licensesFormat string // output format
+)
+
+func addLicensesFlags(fs *flag.FlagSet) {
+	fs.StringVar(&licensesFormat, "format", "text", "output format: text, csv or json")
+}
+
+var cmdLicenses = &Command{
+	Name:      "licenses",
+	UsageLine: "licenses [-format text|csv|json]",
+	Short:     "report the licenses of the dependencies",
+	Long: `licenses reports the license of each dependency.
+
+Licenses are detected from the license files of the dependencies, like LICENSE
+or COPYING, when they are fetched or updated, and recorded in the manifest.
+They are reported as SPDX identifiers, like MIT or Apache-2.0; "unknown" is
+reported for license files which are not recognized.
+
+If the vendor folder holds a licensepolicy file, gvt fetch and gvt update refuse
+dependencies without a license or with a license the policy does not allow.
+Each line of the file is "allow" or "deny" followed by a license identifier;
+when there are allow lines, only those licenses are allowed. licenses then also
+reports the dependencies breaking the policy, and exits with an error if any.
+
+Flags:
+	-format
+		text (the default) prints a table, csv and json print the same
+		fields in those formats.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("licenses: unexpected arguments")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+		policy, err := readLicensePolicy()
+		if err != nil {
+			return err
+		}
+
+		var (
+			reports    []licenseReport
+			violations int
+		)
+		for _, d := range m.Dependencies {
+			r := licenseReport{
+				Importpath: d.Importpath,
+				Repository: d.Repository,
+				License:    d.License,
+			}
+			if r.License == "" {
+				// fetched before licenses were recorded
+				files, err := fileutils.LicenseFiles(filepath.Join(vendorDir, filepath.FromSlash(d.Importpath)))
+				if os.IsNotExist(err) {
+					log.Printf("%s is not vendored, run gvt restore to detect its license", d.Importpath)
+				} else if err != nil {
+					return err
+				}
+				if r.License, err = detectLicense(files); err != nil {
+					return err
+				}
+			}
+			if err := policy.check(d.Importpath, r.License); err != nil {
+				r.Policy = err.(*licenseError).msg
+				violations++
+			}
+			reports = append(reports, r)
+		}
+
+		switch licensesFormat {
+		case "text":
+			w := tabwriter.NewWriter(os.Stdout, 1, 2, 1, ' ', 0)
+			fmt.Fprintln(w, "IMPORTPATH\tLICENSE\tREPOSITORY")
+			for _, r := range reports {
+				license := r.License
+				if license == "" {
+					license = "none"
+				}
+				if r.Policy != "" {
+					license += " (" + r.Policy + ")"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", r.Importpath, license, r.Repository)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			w.Write([]string{"importpath", "license", "repository", "policy"})
+			for _, r := range reports {
+				w.Write([]string{r.Importpath, r.License, r.Repository, r.Policy})
+			}
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
+			}
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "\t")
+			if err := enc.Encode(reports); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown format %q", licensesFormat)
+		}
+
+		if violations > 0 {
+			return fmt.Errorf("%d dependencies break the license policy", violations)
+		}
+		return nil
+	},
+	AddFlags: addLicensesFlags,
+}
+
+// licenseReport is the license of a dependency.
+type licenseReport struct {
+	Importpath string `json:"importpath"`
+	License    string `json:"license"`
+	Repository string `json:"repository"`
+	Policy     string `json:"policy,omitempty"` // why the policy refuses it
+}
+
+// detectLicense returns the licenses of the license files, joined by "AND".
+// Files which are not recognized count as "unknown", notice files like
+// NOTICE or AUTHORS are ignored. It returns "" if there are no licenses.
+func detectLicense(files []string) (string, error) {
+	seen := make(map[string]bool)
+	var licenses []string
+	for _, f := range files {
+		if fileutils.IsNoticeFile(filepath.Base(f)) {
+			continue
+		}
+		text, err := ioutil.ReadFile(f)
+		if err != nil {
+			return "", err
+		}
+		l := vendor.DetectLicense(string(text))
+		if l == "" {
+			l = "unknown"
+		}
+		if !seen[l] {
+			seen[l] = true
+			licenses = append(licenses, l)
+		}
+	}
+	sort.Strings(licenses)
+	return strings.Join(licenses, " AND "), nil
+}
+
+// checkLicense detects the license of a dependency from its license files
+// and checks it against the license policy.
+func checkLicense(importpath string, files []string) (string, error) {
+	license, err := detectLicense(files)
+	if err != nil {
+		return "", err
+	}
+	policy, err := readLicensePolicy()
+	if err != nil {
+		return "", err
+	}
+	return license, policy.check(importpath, license)
+}
+
+// licensePolicy holds the licenses allowed and denied by the licensepolicy
+// file of the vendor folder.
+type licensePolicy struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// readLicensePolicy reads the license policy, or returns nil if there is none.
+func readLicensePolicy() (*licensePolicy, error) {
+	path := filepath.Join(vendorDir, "licensepolicy")
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &licensePolicy{
+		allow: make(map[string]bool),
+		deny:  make(map[string]bool),
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		if j := strings.Index(line, "#"); j >= 0 {
+			line = line[:j]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case len(fields) == 2 && fields[0] == "allow":
+			p.allow[fields[1]] = true
+		case len(fields) == 2 && fields[0] == "deny":
+			p.deny[fields[1]] = true
+		default:
+			return nil, fmt.Errorf("%s:%d: expected \"allow license\" or \"deny license\"", path, i+1)
+		}
+	}
+	return p, nil
+}
+
+// check returns a *licenseError if the policy refuses the license. A nil
+// policy accepts any license.
+func (p *licensePolicy) check(importpath, license string) error {
+	if p == nil {
+		return nil
+	}
+	if license == "" {
+		return &licenseError{importpath, "no license"}
+	}
+	for _, l := range strings.Split(license, " AND ") {
+		if p.deny[l] {
+			return &licenseError{importpath, l + " is denied"}
+		}
+		if len(p.allow) > 0 && !p.allow[l] {
+			return &licenseError{importpath, l + " is not allowed"}
+		}
+	}
+	return nil
+}
+
+// licenseError is returned when the license policy refuses a dependency.
+type licenseError struct {
+	importpath string
+	msg        string
+}
+
+func (e *licenseError) Error() string {
+	return fmt.Sprintf("license policy refuses %s: %s", e.importpath, e.msg)
+}
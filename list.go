@@ -15,7 +15,7 @@ var (
 )
 
 func addListFlags(fs *flag.FlagSet) {
-	fs.StringVar(&format, "f", "{{.Importpath}}\t{{.Repository}}{{.Path}}\t{{.Branch}}\t{{.Revision}}", "format template")
+	fs.StringVar(&format, "f", "{{.Importpath}}\t{{.Repository}}{{.Path}}\t{{.Branch}}\t{{.Revision}}{{if .Replace}}\treplaced by {{.Replace}}{{end}}", "format template")
 }
 
 var cmdList = &Command{
@@ -24,10 +24,12 @@ var cmdList = &Command{
 	Short:     "list dependencies one per line",
 	Long: `list formats the contents of the manifest file.
 
+Dependencies replaced by a local directory are marked with the directory.
+
 Flags:
 	-f
 		controls the template used for printing each manifest entry. If not supplied
-		the default value is "{{.Importpath}}\t{{.Repository}}{{.Path}}\t{{.Branch}}\t{{.Revision}}"
+		the default value is "{{.Importpath}}\t{{.Repository}}{{.Path}}\t{{.Branch}}\t{{.Revision}}{{if .Replace}}\treplaced by {{.Replace}}{{end}}"
 
 `,
 	Run: func(args []string) error {
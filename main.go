@@ -37,6 +37,21 @@ var commands = []*Command{
 	cmdUpdate,
 	cmdList,
 	cmdDelete,
+	cmdDiff,
+	cmdPatch,
+	cmdReplace,
+	cmdOutdated,
+	cmdPrune,
+	cmdUnused,
+	cmdMissing,
+	cmdGraph,
+	cmdWhy,
+	cmdLicenses,
+	cmdNotices,
+	cmdSbom,
+	cmdAudit,
+	cmdImport,
+	cmdExport,
 }
 
 func main() {
@@ -72,7 +87,9 @@ func main() {
 				os.Exit(3)
 			}
 
-			if err := command.Run(fs.Args()); err != nil {
+			err := command.Run(fs.Args())
+			reportParseErrors()
+			if err != nil {
 				log.Fatalf("command %q failed: %v", command.Name, err)
 			}
 			if err := GlobalDownloader.Flush(); err != nil {
@@ -163,4 +180,10 @@ func init() {
 			}
 		}
 	}
+
+	if p := configFile("importRules"); p != "" {
+		if importRules, err = readImportRules(p); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
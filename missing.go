@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var cmdMissing = &Command{
+	Name:      "missing",
+	UsageLine: "missing [-strict]",
+	Short:     "list imports which are not vendored",
+	Long: `missing lists the imports of the project, and of the vendored packages it uses,
+which are neither vendored, part of the project nor part of the standard library.
+
+It exits with an error if any import is missing, so that it can be used to
+check the vendor folder in continuous integration.
+
+Flags:
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("missing: unexpected arguments")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		g, err := loadImportGraph(m)
+		if err != nil {
+			return fmt.Errorf("could not parse the imports of the project: %v", err)
+		}
+
+		missing := g.missing()
+		for _, p := range missing {
+			fmt.Println(p)
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%d missing imports", len(missing))
+		}
+		return nil
+	},
+	AddFlags: addStrictFlag,
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+// upstreamPin is a version of a dependency pinned by a fetched dependency,
+// either a module required by its go.mod file or a dependency locked by its
+// vendor/manifest or the lock file of another vendoring tool.
+type upstreamPin struct {
+	version  string // a tag or a pseudo-version
+	revision string // a revision, which takes precedence over version
+	source   string // import path or repository URL of its replacement, if any
+}
+
+// upstreamPins are the versions pinned by the dependencies fetched during
+// this session, by import path.
+var upstreamPins = make(map[string]upstreamPin)
+
+// addUpstreamPin records a pinned version of the dependency importpath. When
+// several dependencies pin it, the highest version wins, and a version wins
+// over a revision without version. Other conflicts keep the first pin.
+func addUpstreamPin(importpath string, pin upstreamPin) {
+	if fetchLatest {
+		return
+	}
+	old, ok := upstreamPins[importpath]
+	switch {
+	case !ok || old == pin:
+	case old.version != "" && pin.version != "":
+		if !newerVersion(pin.version, old.version) {
+			return
+		}
+	case pin.version == "":
+		log.Printf("%s: pinned at %s and %s, using %s", importpath, old, pin, old)
+		return
+	default:
+		log.Printf("%s: pinned at %s and %s, using %s", importpath, old, pin, pin)
+	}
+	upstreamPins[importpath] = pin
+}
+
+// String returns the version of the pin, or else its abbreviated revision.
+func (p upstreamPin) String() string {
+	if p.version != "" {
+		return p.version
+	}
+	return shortRev(p.revision)
+}
+
+// readUpstreamPins records the versions pinned by the dependency whose
+// source is src, a folder of the working copy wcDir: the modules required by
+// the go.mod file of its module, and the dependencies locked by the closest
+// vendor/manifest or lock file of another vendoring tool. It returns the
+// go.mod file, or nil if the dependency is not a module.
+func readUpstreamPins(src, wcDir string) (*vendor.GoMod, error) {
+	var (
+		gomod  *vendor.GoMod
+		locked bool
+		err    error
+	)
+	for dir := src; strings.HasPrefix(dir, wcDir); dir = filepath.Dir(dir) {
+		if p := filepath.Join(dir, "go.mod"); gomod == nil && isFile(p) {
+			if gomod, err = readModulePins(p); err != nil {
+				return nil, err
+			}
+		}
+		if !locked {
+			if locked, err = readLockPins(dir); err != nil {
+				return nil, err
+			}
+		}
+		if dir == wcDir {
+			break
+		}
+	}
+	return gomod, nil
+}
+
+// readModulePins records the modules required by a go.mod file.
+func readModulePins(path string) (*vendor.GoMod, error) {
+	gomod, err := vendor.ReadGoMod(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range gomod.Require {
+		pin := upstreamPin{version: r.Version}
+		if n, ok := gomod.Replacement(r); ok {
+			if n.Version == "" {
+				// a local folder of the dependency
+				continue
+			}
+			pin = upstreamPin{version: n.Version, source: n.Path}
+		}
+		if rev := vendor.PseudoVersionRevision(pin.version); rev != "" {
+			pin.revision = rev
+		}
+		addUpstreamPin(r.Path, pin)
+	}
+	return gomod, nil
+}
+
+// readLockPins records the dependencies locked by the vendor/manifest file
+// of dir, or else by the first lock file of another vendoring tool found in
+// dir. It reports whether there was one.
+func readLockPins(dir string) (bool, error) {
+	if p := filepath.Join(dir, "vendor", "manifest"); isFile(p) {
+		m, err := vendor.ReadManifest(p)
+		if err != nil {
+			return false, fmt.Errorf("could not load manifest: %v", err)
+		}
+		for _, d := range m.Dependencies {
+			addUpstreamPin(d.Importpath, upstreamPin{version: d.Tag, revision: d.Revision})
+		}
+		return true, nil
+	}
+	for _, l := range vendor.LockFiles {
+		p := filepath.Join(dir, filepath.FromSlash(l.Name))
+		if l.Format == "gomod" || !isFile(p) {
+			continue
+		}
+		locked, err := vendor.ReadLockFile(l.Format, p)
+		if err != nil {
+			return false, err
+		}
+		for _, d := range locked {
+			addUpstreamPin(d.Importpath, upstreamPin{version: d.Version, revision: d.Revision, source: d.Source})
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// isFile reports whether path is a regular file.
+func isFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// newerVersion reports whether module version v is higher than w.
+func newerVersion(v, w string) bool {
+	a, err := vendor.ParseVersion(strings.TrimSuffix(v, "+incompatible"))
+	if err != nil {
+		return false
+	}
+	b, err := vendor.ParseVersion(strings.TrimSuffix(w, "+incompatible"))
+	return err == nil && a.Compare(b) > 0
+}
+
+// pinnedDependency returns the pinned dependency holding the package path,
+// if any.
+func pinnedDependency(path string) (string, *upstreamPin) {
+	var mod string
+	for m := range upstreamPins {
+		if contains(m, path) && len(m) > len(mod) {
+			mod = m
+		}
+	}
+	if mod == "" {
+		return "", nil
+	}
+	pin := upstreamPins[mod]
+	return mod, &pin
+}
+
+// majorSuffix matches a path with a major version element, like
+// /sub/v2/pkg.
+var majorSuffix = regexp.MustCompile(`^(.*?)/v([2-9]|[1-9]\d+)(/.*)?$`)
+
+// checkoutModule checks out the repository of a dependency fetched
+// recursively, where extra is the path of the package inside it. A pinned
+// dependency, whose package path is rest, is checked out at its version. A
+// package of a major version 2 or more, like example.com/mod/v2/pkg, is
+// taken from the v2 subfolder of the repository if it holds the module, or
+// else from the newest v2 tag or the v2 branch. It returns the working copy,
+// the path of the package inside it and the tag checked out, if any.
+func checkoutModule(path string, repo vendor.RemoteRepo, branch, extra string, pin *upstreamPin, rest string) (vendor.WorkingCopy, string, string, error) {
+	// the folder of the module in the repository, like /sub/v2
+	var modDir string
+	if pin != nil && strings.HasSuffix(extra, rest) {
+		modDir = strings.TrimSuffix(extra, rest)
+	} else if m := majorSuffix.FindStringSubmatch(extra); m != nil {
+		modDir = m[1] + "/v" + m[2]
+	}
+	dir, major := modDir, 0
+	if m := majorSuffix.FindStringSubmatch(modDir); m != nil && m[3] == "" {
+		dir = m[1]
+		major, _ = strconv.Atoi(m[2])
+	}
+	// tags of modules in subfolders are prefixed by the folder
+	tagPrefix := strings.TrimPrefix(dir, "/")
+	if tagPrefix != "" {
+		tagPrefix += "/"
+	}
+
+	var tag, rev string
+	if pin != nil {
+		branch = ""
+		if rev = pin.revision; rev == "" {
+			tag = tagPrefix + strings.TrimSuffix(pin.version, "+incompatible")
+		}
+	}
+	wc, err := getWorkingCopy(path, repo, branch, tag, rev)
+	if err != nil || major == 0 {
+		return wc, extra, tag, err
+	}
+	if _, err := os.Stat(filepath.Join(wc.Dir(), modDir, "go.mod")); err == nil {
+		// the major version is a subfolder
+		return wc, extra, tag, nil
+	}
+
+	// the major version is in a branch or in tags of the module folder
+	extra = dir + strings.TrimPrefix(extra, modDir)
+	if pin != nil {
+		return wc, extra, tag, nil
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("could not list the tags of %s: %v", repo.URL(), err)
+	}
+	var versions []string
+	for _, t := range tags {
+		if strings.HasPrefix(t, tagPrefix) {
+			versions = append(versions, strings.TrimPrefix(t, tagPrefix))
+		}
+	}
+	if latest, ok := vendor.LatestVersion(versions, func(v vendor.Version) bool { return v.Major == major }); ok {
+		tag = tagPrefix + latest
+		wc, err := getWorkingCopy(path, repo, "", tag, "")
+		return wc, extra, tag, err
+	}
+	wc, err = getWorkingCopy(path, repo, fmt.Sprintf("v%d", major), "", "")
+	if err != nil {
+		return nil, "", "", fmt.Errorf("no v%d tag or branch found: %v", major, err)
+	}
+	return wc, extra, "", nil
+}
+
+// getWorkingCopy checks out repo for the package path, trying three times.
+func getWorkingCopy(path string, repo vendor.RemoteRepo, branch, tag, rev string) (vendor.WorkingCopy, error) {
+	wc, err := GlobalDownloader.Get(repo, branch, tag, rev, verbose)
+	for i := 0; err != nil && i < 2; i++ {
+		fmt.Println("download " + path + " fail, retry.")
+		wc, err = GlobalDownloader.Get(repo, branch, tag, rev, verbose)
+	}
+	return wc, err
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/uk702/gvt/fileutils"
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	noticesOutput string // file to write
+	noticesFormat string // output format
+)
+
+func addNoticesFlags(fs *flag.FlagSet) {
+	fs.StringVar(&noticesOutput, "o", "", "file to write the notices to")
+	fs.StringVar(&noticesFormat, "format", "text", "output format: text or markdown")
+}
+
+var cmdNotices = &Command{
+	Name:      "notices",
+	UsageLine: "notices [-o file] [-format text|markdown]",
+	Short:     "generate a third party notices document",
+	Long: `notices generates a document with the license and notice files of all the
+dependencies, like a THIRD_PARTY_NOTICES file to ship with a release.
+
+For each dependency it includes the repository and revision it was vendored
+from, its license, and the license files copied to its vendor folder, like
+LICENSE, NOTICE or PATENTS.
+
+Flags:
+	-o file
+		write the document to file instead of the standard output.
+	-format
+		text (the default) or markdown.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("notices: unexpected arguments")
+		}
+		if noticesFormat != "text" && noticesFormat != "markdown" {
+			return fmt.Errorf("unknown format %q", noticesFormat)
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if noticesFormat == "markdown" {
+			fmt.Fprintf(&buf, "# Third party notices\n\nThis project includes the following third party software.\n")
+		} else {
+			fmt.Fprintf(&buf, "THIRD PARTY NOTICES\n\nThis project includes the following third party software.\n")
+		}
+		for _, d := range m.Dependencies {
+			files, err := fileutils.LicenseFiles(filepath.Join(vendorDir, filepath.FromSlash(d.Importpath)))
+			switch {
+			case os.IsNotExist(err):
+				log.Printf("%s is not vendored, run gvt restore to include its license files", d.Importpath)
+			case err != nil:
+				return err
+			case len(files) == 0:
+				log.Printf("%s has no license files", d.Importpath)
+			}
+			license := d.License
+			if license == "" {
+				if license, err = detectLicense(files); err != nil {
+					return err
+				}
+			}
+			if err := writeNotice(&buf, d, license, files); err != nil {
+				return err
+			}
+		}
+
+		if noticesOutput == "" {
+			_, err := buf.WriteTo(os.Stdout)
+			return err
+		}
+		return ioutil.WriteFile(noticesOutput, buf.Bytes(), 0644)
+	},
+	AddFlags: addNoticesFlags,
+}
+
+// writeNotice writes the section of dependency d in the notices document.
+func writeNotice(w io.Writer, d vendor.Dependency, license string, files []string) error {
+	if license == "" {
+		license = "none found"
+	}
+	markdown := noticesFormat == "markdown"
+
+	if markdown {
+		fmt.Fprintf(w, "\n## %s\n\n", d.Importpath)
+		fmt.Fprintf(w, "* Repository: %s\n", d.Repository)
+		fmt.Fprintf(w, "* Revision: `%s`\n", d.Revision)
+		fmt.Fprintf(w, "* License: %s\n", license)
+	} else {
+		fmt.Fprintf(w, "\n%s\n%s\n\n", strings.Repeat("=", 80), d.Importpath)
+		fmt.Fprintf(w, "Repository: %s\n", d.Repository)
+		fmt.Fprintf(w, "Revision: %s\n", d.Revision)
+		fmt.Fprintf(w, "License: %s\n", license)
+	}
+
+	for _, f := range files {
+		text, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(f)
+		text = bytes.TrimRight(text, "\n")
+		if markdown {
+			fence := "```"
+			if bytes.Contains(text, []byte(fence)) {
+				fence = "~~~~"
+			}
+			fmt.Fprintf(w, "\n### %s\n\n%s\n%s\n%s\n", name, fence, text, fence)
+		} else {
+			fmt.Fprintf(w, "\n----- %s -----\n\n%s\n", name, text)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	outdatedJSON        bool // print JSON
+	outdatedConnections uint // count of concurrent download connections
+)
+
+func addOutdatedFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&outdatedJSON, "json", false, "print JSON")
+	fs.UintVar(&outdatedConnections, "connections", 8, "count of parallel download connections")
+	fs.BoolVar(&insecure, "precaire", false, "allow the use of insecure protocols")
+}
+
+var cmdOutdated = &Command{
+	Name:      "outdated",
+	UsageLine: "outdated [-json] [-precaire] [-connections N] [importpath...]",
+	Short:     "report dependencies behind their upstream",
+	Long: `outdated reports how far dependencies are behind their upstream, without changing them.
+
+For each dependency it prints the vendored revision and the date of its commit,
+the revision gvt update would move to, and the number of commits in between.
+Dependencies fetched with -tag or -version are compared against the newest
+matching tag, the others against the head of their branch.
+
+If no import path is supplied, all dependencies are reported.
+
+Flags:
+	-json
+		print the report as JSON.
+	-precaire
+		allow the use of insecure protocols.
+	-connections
+		count of parallel download connections.
+
+`,
+	Run: func(args []string) error {
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		dependencies := m.Dependencies
+		if len(args) > 0 {
+			dependencies = nil
+			for _, p := range args {
+				dependency, err := m.GetDependencyForImportpath(p)
+				if err != nil {
+					return fmt.Errorf("could not get dependency: %v", err)
+				}
+				dependencies = append(dependencies, dependency)
+			}
+		}
+
+		reports := make([]outdatedReport, len(dependencies))
+		var wg sync.WaitGroup
+		idxC := make(chan int)
+		for i := 0; i < int(outdatedConnections); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range idxC {
+					reports[i] = checkOutdated(dependencies[i])
+				}
+			}()
+		}
+		for i := range dependencies {
+			idxC <- i
+		}
+		close(idxC)
+		wg.Wait()
+
+		var errors int
+		for _, r := range reports {
+			if r.Error != "" {
+				errors++
+			}
+		}
+
+		if outdatedJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "\t")
+			if err := enc.Encode(reports); err != nil {
+				return err
+			}
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 1, 2, 1, ' ', 0)
+			fmt.Fprintln(w, "IMPORTPATH\tREVISION\tDATE\tLATEST\tBEHIND")
+			for _, r := range reports {
+				if r.Error != "" {
+					fmt.Fprintf(w, "%s\t%s\terror: %s\n", r.Importpath, shortRev(r.Revision), r.Error)
+					continue
+				}
+				latest := shortRev(r.Latest)
+				if r.LatestTag != "" {
+					latest = r.LatestTag + " (" + latest + ")"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", r.Importpath, shortRev(r.Revision),
+					r.Date.Format("2006-01-02"), latest, r.Behind)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+
+		if errors > 0 {
+			return fmt.Errorf("failed to check %d dependencies", errors)
+		}
+		return nil
+	},
+	AddFlags: addOutdatedFlags,
+}
+
+// outdatedReport describes how far a dependency is behind its upstream.
+type outdatedReport struct {
+	Importpath string    `json:"importpath"`
+	Revision   string    `json:"revision"`
+	Date       time.Time `json:"date"`
+	Latest     string    `json:"latest,omitempty"`
+	LatestTag  string    `json:"latesttag,omitempty"`
+	Behind     int       `json:"behind"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func checkOutdated(dep vendor.Dependency) outdatedReport {
+	r := outdatedReport{
+		Importpath: dep.Importpath,
+		Revision:   dep.Revision,
+	}
+	if err := r.check(dep); err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+func (r *outdatedReport) check(dep vendor.Dependency) error {
+	repo, err := vendor.NewRemoteRepo(dep.Repository, dep.VCS, insecure)
+	if err != nil {
+		return fmt.Errorf("could not determine repository for import %q", dep.Importpath)
+	}
+
+	// a full clone is needed to count commits, so check out the revision
+	wc, err := GlobalDownloader.Get(repo, "", "", dep.Revision, false)
+	if err != nil {
+		return fmt.Errorf("dependency could not be fetched: %s", err)
+	}
+	history, ok := wc.(vendor.History)
+	if !ok {
+		return fmt.Errorf("history of %s repositories is not supported", dep.VCS)
+	}
+
+	commit, err := history.Commit(dep.Revision)
+	if err != nil {
+		return err
+	}
+	r.Date = commit.Date
+
+	ref := dep.Branch
+	switch {
+	case dep.Constraint != "":
+		r.LatestTag, err = semverTag(repo, dep.Constraint)
+		ref = r.LatestTag
+	case dep.Tag != "":
+		// a tag which is not a semantic version is compared to itself
+		r.LatestTag, _, err = latestTag(repo, dep.Tag)
+		ref = r.LatestTag
+	case ref == "HEAD":
+		// fetched with -revision, compare with the default branch
+		ref = ""
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.Latest, err = history.Resolve(ref); err != nil {
+		return err
+	}
+	commits, err := history.Log(dep.Revision, r.Latest)
+	if err != nil {
+		return err
+	}
+	r.Behind = len(commits)
+	return nil
+}
+
+// shortRev abbreviates a revision for display.
+func shortRev(rev string) string {
+	if len(rev) > 12 {
+		return rev[:12]
+	}
+	return rev
+}
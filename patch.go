@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/uk702/gvt/fileutils"
+	"github.com/uk702/gvt/gbvendor"
+)
+
+// patchesDir is the folder, relative to the vendor folder, holding the local
+// patches of dependencies.
+const patchesDir = ".patches"
+
+func addPatchFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&insecure, "precaire", false, "allow the use of insecure protocols")
+}
+
+var cmdPatch = &Command{
+	Name:      "patch",
+	UsageLine: "patch [-precaire] save | drop importpath",
+	Short:     "record local modifications of a dependency",
+	Long: `patch manages the local patches of a dependency.
+
+Patches are kept in vendor/.patches/importpath/ and listed in the manifest.
+They are applied in order on top of the upstream source by fetch, update and
+restore, which fail if a patch does not apply cleanly anymore.
+
+Patches are not removed by delete, a later fetch of the same import path will
+pick them up again.
+
+Subcommands:
+	save importpath
+		record the local modifications of the dependency that are not covered
+		by its existing patches as a new patch.
+	drop importpath
+		remove all the patches of the dependency. The vendor folder is left untouched.
+
+Flags:
+	-precaire
+		allow the use of insecure protocols.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("patch: subcommand and import path are required")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		p := args[1]
+		dependency, err := m.GetDependencyForImportpath(p)
+		if err != nil {
+			return fmt.Errorf("could not get dependency: %v", err)
+		}
+		if p != dependency.Importpath {
+			return fmt.Errorf("a parent of the specified dependency is vendored, patch that instead: %v",
+				dependency.Importpath)
+		}
+
+		var dep vendor.Dependency
+		switch args[0] {
+		case "save":
+			dep, err = savePatch(dependency)
+		case "drop":
+			dep, err = dropPatches(dependency)
+		default:
+			return fmt.Errorf("unknown patch subcommand: %q", args[0])
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := m.RemoveDependency(dependency); err != nil {
+			return fmt.Errorf("dependency could not be deleted from manifest: %v", err)
+		}
+		if err := m.AddDependency(dep); err != nil {
+			return err
+		}
+		return vendor.WriteManifest(manifestFile, m)
+	},
+	AddFlags: addPatchFlags,
+}
+
+// savePatch writes the difference between the patched upstream source and the
+// vendor folder to a new patch, and returns dep with the patch added.
+func savePatch(dep vendor.Dependency) (vendor.Dependency, error) {
+	upstream, err := upstreamCopy(dep)
+	if err != nil {
+		return dep, err
+	}
+	defer fileutils.RemoveAll(upstream)
+
+	if err := applyPatches(upstream, vendorDir, dep); err != nil {
+		return dep, err
+	}
+
+	var buf bytes.Buffer
+	local := filepath.Join(vendorDir, filepath.FromSlash(dep.Importpath))
+	changed, err := diffDirs(&buf, upstream, local, "")
+	if err != nil {
+		return dep, err
+	}
+	if !changed {
+		return dep, fmt.Errorf("%s has no local modifications to save", dep.Importpath)
+	}
+
+	dir := filepath.Join(vendorDir, patchesDir, filepath.FromSlash(dep.Importpath))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return dep, err
+	}
+	var name string
+	for n := len(dep.Patches) + 1; ; n++ {
+		name = fmt.Sprintf("%04d.patch", n)
+		if !fileutils.IsFileExist(filepath.Join(dir, name)) {
+			break
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0644); err != nil {
+		return dep, err
+	}
+
+	patch := path.Join(patchesDir, dep.Importpath, name)
+	fmt.Println("Saved", patch)
+	dep.Patches = append(append([]string(nil), dep.Patches...), patch)
+	return dep, nil
+}
+
+// dropPatches removes the patches of dep, and returns dep without them.
+func dropPatches(dep vendor.Dependency) (vendor.Dependency, error) {
+	if len(dep.Patches) == 0 {
+		return dep, fmt.Errorf("%s has no patches", dep.Importpath)
+	}
+	for _, p := range dep.Patches {
+		if err := os.Remove(filepath.Join(vendorDir, filepath.FromSlash(p))); err != nil && !os.IsNotExist(err) {
+			return dep, err
+		}
+	}
+	dir := filepath.Join(vendorDir, patchesDir, filepath.FromSlash(dep.Importpath))
+	if files, _ := ioutil.ReadDir(dir); len(files) == 0 {
+		fileutils.RemoveAll(dir)
+	}
+	dep.Patches = nil
+	return dep, nil
+}
+
+// findPatches returns the patches stored for importpath, relative to vendorDir.
+func findPatches(vendorDir, importpath string) ([]string, error) {
+	dir := filepath.Join(vendorDir, patchesDir, filepath.FromSlash(importpath))
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var patches []string
+	for _, f := range files {
+		if !f.IsDir() && filepath.Ext(f.Name()) == ".patch" {
+			patches = append(patches, path.Join(patchesDir, importpath, f.Name()))
+		}
+	}
+	sort.Strings(patches)
+	return patches, nil
+}
+
+// patchError is returned when a patch does not apply.
+type patchError struct {
+	patch string
+	msg   string
+}
+
+func (e *patchError) Error() string {
+	return fmt.Sprintf("patch %s does not apply: %s", e.patch, e.msg)
+}
+
+// applyPatches applies the patches of dep, relative to vendorDir, to the copy
+// of the dependency in dir.
+func applyPatches(dir, vendorDir string, dep vendor.Dependency) error {
+	for _, p := range dep.Patches {
+		if err := fileutils.ApplyPatch(dir, filepath.Join(vendorDir, filepath.FromSlash(p))); err != nil {
+			return &patchError{patch: p, msg: err.Error()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/uk702/gvt/fileutils"
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	pruneDryRun bool   // only print what would be removed
+	pruneKeep   string // comma separated import paths to keep
+)
+
+func addPruneFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&pruneDryRun, "dry-run", false, "only print what would be removed")
+	fs.StringVar(&pruneKeep, "keep", "", "comma separated import paths to keep")
+	addStrictFlag(fs)
+}
+
+var cmdPrune = &Command{
+	Name:      "prune",
+	UsageLine: "prune [-dry-run] [-keep importpath,...] [-strict]",
+	Short:     "remove unused vendored packages",
+	Long: `prune removes the dependencies and the vendored packages that the project does not use.
+
+It parses the packages of the project, including their tests, and follows
+their imports through the vendor folder. Only the files matching the build
+tags recorded by gvt fetch -tags are parsed. Dependencies of which no package is
+reachable are deleted from the vendor folder and the manifest, and the
+unreachable packages of the other dependencies are removed.
+
+Packages which are only imported by files excluded from the parsing, like
+cgo helpers, can be protected with -keep. Keeping a path keeps the packages
+below it too.
+
+Flags:
+	-dry-run
+		only print what would be removed, without changing anything.
+	-keep
+		comma separated list of import paths to keep.
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("prune: unexpected arguments")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		g, err := loadImportGraph(m)
+		if err != nil {
+			return fmt.Errorf("could not parse the imports of the project: %v", err)
+		}
+
+		var keep []string
+		for _, k := range strings.Split(pruneKeep, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keep = append(keep, k)
+			}
+		}
+
+		// used reports whether a vendored package, or a package below it,
+		// is reachable or kept.
+		used := func(p string) bool {
+			for _, k := range keep {
+				if contains(k, p) || contains(p, k) {
+					return true
+				}
+			}
+			return g.uses(p)
+		}
+
+		dependencies := make([]vendor.Dependency, len(m.Dependencies))
+		copy(dependencies, m.Dependencies)
+		for _, d := range dependencies {
+			if d.Symlink {
+				// the packages belong to the replacement directory
+				if !used(d.Importpath) {
+					log.Printf("%s is not used, it can be removed with gvt replace -drop and gvt delete", d.Importpath)
+				}
+				continue
+			}
+
+			dir := filepath.Join(vendorDir, filepath.FromSlash(d.Importpath))
+			if !used(d.Importpath) {
+				log.Printf("Removing unused dependency: %s", d.Importpath)
+				if pruneDryRun {
+					continue
+				}
+				if err := m.RemoveDependency(d); err != nil {
+					return fmt.Errorf("dependency could not be deleted: %v", err)
+				}
+				if err := fileutils.RemoveAll(dir); err != nil {
+					return fmt.Errorf("dependency could not be deleted: %v", err)
+				}
+				if err := removeEmptyParents(filepath.Dir(dir)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := prunePackages(dir, used); err != nil {
+				return fmt.Errorf("could not prune %s: %v", d.Importpath, err)
+			}
+		}
+
+		if pruneDryRun {
+			return nil
+		}
+		return vendor.WriteManifest(manifestFile, m)
+	},
+	AddFlags: addPruneFlags,
+}
+
+// prunePackages removes the packages in the dependency folder dir for which
+// used returns false.
+func prunePackages(dir string, used func(string) bool) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || p == dir {
+			return nil
+		}
+		if fileutils.ShouldSkip(p, info, false, false) {
+			// testdata and hidden folders are not packages
+			return filepath.SkipDir
+		}
+		if !hasGoFiles(p) {
+			return nil
+		}
+		rel, err := filepath.Rel(vendorDir, p)
+		if err != nil {
+			return err
+		}
+		if pkg := filepath.ToSlash(rel); !used(pkg) {
+			log.Printf("Removing unused package: %s", pkg)
+			if !pruneDryRun {
+				if err := fileutils.RemoveAll(p); err != nil {
+					return err
+				}
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// removeEmptyParents removes dir and its parents up to the vendor folder, as
+// long as they are empty.
+func removeEmptyParents(dir string) error {
+	for dir != vendorDir && strings.HasPrefix(dir, vendorDir) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		if len(files) > 0 {
+			return nil
+		}
+		if err := os.Remove(dir); err != nil {
+			return err
+		}
+		dir = filepath.Dir(dir)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/uk702/gvt/fileutils"
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	replaceDrop    bool // drop the replacement
+	replaceSymlink bool // link to the replacement instead of copying it
+)
+
+func addReplaceFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&replaceDrop, "drop", false, "drop the replacement")
+	fs.BoolVar(&replaceSymlink, "symlink", false, "symlink the replacement")
+	fs.BoolVar(&rbInsecure, "precaire", false, "allow the use of insecure protocols")
+}
+
+var cmdReplace = &Command{
+	Name:      "replace",
+	UsageLine: "replace [-symlink] importpath dir | -drop [-precaire] importpath",
+	Short:     "use a local directory for a dependency",
+	Long: `replace takes a vendored dependency from a local directory instead of its repository.
+
+The directory is expected to hold the code of the import path, for example a
+checkout of its repository. The repository and revision in the manifest are kept,
+and restore will copy the dependency from the directory until the replacement is
+dropped. Replaced dependencies are skipped by update.
+
+Flags:
+	-symlink
+		make the vendor folder a symbolic link to the directory instead of a copy.
+	-drop
+		drop the replacement and restore the dependency from its repository.
+	-precaire
+		allow the use of insecure protocols.
+
+`,
+	Run: func(args []string) error {
+		if replaceDrop && len(args) != 1 {
+			return fmt.Errorf("replace: -drop takes exactly one import path")
+		} else if !replaceDrop && len(args) != 2 {
+			return fmt.Errorf("replace: import path and directory are required")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		p := args[0]
+		dependency, err := m.GetDependencyForImportpath(p)
+		if err != nil {
+			return fmt.Errorf("could not get dependency: %v", err)
+		}
+		if p != dependency.Importpath {
+			return fmt.Errorf("a parent of the specified dependency is vendored, replace that instead: %v",
+				dependency.Importpath)
+		}
+
+		dep := dependency
+		if replaceDrop {
+			if dep.Replace == "" {
+				return fmt.Errorf("%s is not replaced", dep.Importpath)
+			}
+			dep.Replace = ""
+			dep.Symlink = false
+			var errors uint32
+			if err := downloadDependency(dep, &errors, vendorDir, false); err != nil {
+				return err
+			}
+		} else {
+			dep.Replace = filepath.ToSlash(args[1])
+			dep.Symlink = replaceSymlink
+			if err := copyReplacement(dep, vendorDir); err != nil {
+				return err
+			}
+		}
+
+		if err := m.RemoveDependency(dependency); err != nil {
+			return fmt.Errorf("dependency could not be deleted from manifest: %v", err)
+		}
+		if err := m.AddDependency(dep); err != nil {
+			return err
+		}
+		return vendor.WriteManifest(manifestFile, m)
+	},
+	AddFlags: addReplaceFlags,
+}
+
+// replacementDir returns the absolute path of the local replacement of dep.
+func replacementDir(dep vendor.Dependency) string {
+	dir := filepath.FromSlash(dep.Replace)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(vendorDir), dir)
+	}
+	return dir
+}
+
+// copyReplacement replaces the copy of dep in vendorDir with its local replacement.
+func copyReplacement(dep vendor.Dependency, vendorDir string) error {
+	src := replacementDir(dep)
+	if info, err := os.Stat(src); err != nil {
+		return fmt.Errorf("replacement could not be read: %v", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("replacement %s is not a directory", src)
+	}
+	log.Printf("replacing %s with %s", dep.Importpath, src)
+
+	dst := filepath.Join(vendorDir, filepath.FromSlash(dep.Importpath))
+	if err := fileutils.RemoveAll(dst); err != nil {
+		return fmt.Errorf("dependency could not be deleted: %v", err)
+	}
+
+	if dep.Symlink {
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		return os.Symlink(src, dst)
+	}
+
+	if err := fileutils.Copypath(dst, src, !dep.NoTests, dep.AllFiles); err != nil {
+		return err
+	}
+	// the license of a subpackage may be at the root of its repository
+	return fileutils.CopyLicense(dst, src, fileutils.RepositoryRoot(src))
+}
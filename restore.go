@@ -91,6 +91,10 @@ func restore(manFile string) error {
 }
 
 func downloadDependency(dep vendor.Dependency, errors *uint32, vendorDir string, recursive bool) error {
+	if dep.Replace != "" {
+		return copyReplacement(dep, vendorDir)
+	}
+
 	extraMsg := ""
 	if !dep.NoTests {
 		extraMsg = "(including tests)"
@@ -127,7 +131,11 @@ func downloadDependency(dep vendor.Dependency, errors *uint32, vendorDir string,
 		return err
 	}
 
-	if err := fileutils.CopyLicense(dst, wc.Dir()); err != nil {
+	if err := fileutils.CopyLicense(dst, src, wc.Dir()); err != nil {
+		return err
+	}
+
+	if err := applyPatches(dst, vendorDir, dep); err != nil {
 		return err
 	}
 
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/uk702/gvt/fileutils"
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var (
+	sbomFormat string // output format
+	sbomOutput string // file to write
+)
+
+func addSbomFlags(fs *flag.FlagSet) {
+	fs.StringVar(&sbomFormat, "format", "spdx-json", "output format: spdx-json or cyclonedx-json")
+	fs.StringVar(&sbomOutput, "o", "", "file to write the bill of materials to")
+}
+
+var cmdSbom = &Command{
+	Name:      "sbom",
+	UsageLine: "sbom [-format spdx-json|cyclonedx-json] [-o file]",
+	Short:     "export a software bill of materials",
+	Long: `sbom exports the dependencies of the manifest as a software bill of materials.
+
+Each dependency is described by its import path, version (its tag, or else its
+revision), repository, license and a SHA-256 hash of its vendor folder.
+Dependencies which are not vendored, before a "gvt restore", have no hash and
+the license recorded in the manifest. The project is the root component,
+depending on all of them.
+
+Flags:
+	-format
+		spdx-json (the default) for an SPDX 2.3 document, or cyclonedx-json
+		for a CycloneDX 1.4 one.
+	-o file
+		write the bill of materials to file instead of the standard output.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("sbom: unexpected arguments")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		var components []sbomComponent
+		for _, d := range m.Dependencies {
+			c, err := newSbomComponent(d)
+			if err != nil {
+				return fmt.Errorf("could not describe %s: %v", d.Importpath, err)
+			}
+			components = append(components, c)
+		}
+
+		// the document is identified by the contents of the manifest
+		manifest, err := ioutil.ReadFile(manifestFile)
+		if err != nil {
+			return err
+		}
+		id := sha256.Sum256(manifest)
+		created := time.Now().UTC().Format(time.RFC3339)
+
+		var doc interface{}
+		switch sbomFormat {
+		case "spdx-json":
+			doc = spdxDocument(components, id, created)
+		case "cyclonedx-json":
+			doc = cycloneDXDocument(components, id, created)
+		default:
+			return fmt.Errorf("unknown format %q", sbomFormat)
+		}
+
+		out := os.Stdout
+		if sbomOutput != "" {
+			if out, err = os.Create(sbomOutput); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	},
+	AddFlags: addSbomFlags,
+}
+
+// sbomComponent is a dependency in a bill of materials.
+type sbomComponent struct {
+	vendor.Dependency
+	version string
+	license string // SPDX expression, or "" if unknown
+	hash    string // SHA-256 of the vendor folder
+}
+
+func newSbomComponent(d vendor.Dependency) (sbomComponent, error) {
+	c := sbomComponent{
+		Dependency: d,
+		version:    d.Tag,
+		license:    d.License,
+	}
+	if c.version == "" {
+		c.version = d.Revision
+	}
+	dir := filepath.Join(vendorDir, filepath.FromSlash(d.Importpath))
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		log.Printf("%s is not vendored, run gvt restore to include its hash", d.Importpath)
+		c.license = sbomLicense(c.license)
+		return c, nil
+	}
+	if c.license == "" {
+		files, err := fileutils.LicenseFiles(dir)
+		if err != nil {
+			return c, err
+		}
+		if c.license, err = detectLicense(files); err != nil {
+			return c, err
+		}
+	}
+	c.license = sbomLicense(c.license)
+	var err error
+	c.hash, err = fileutils.HashDir(dir)
+	return c, err
+}
+
+// sbomLicense returns the license of a component, or "" if it is not an SPDX
+// license expression.
+func sbomLicense(license string) string {
+	for _, l := range strings.Split(license, " AND ") {
+		if l == "unknown" {
+			return ""
+		}
+	}
+	return license
+}
+
+func (c sbomComponent) purl() string {
+	return "pkg:golang/" + c.Importpath + "@" + c.version
+}
+
+// downloadLocation returns the location of the component in the SPDX
+// <vcs>+<url>@<revision> form.
+func (c sbomComponent) downloadLocation() string {
+	loc := c.Repository + "@" + c.Revision
+	if c.VCS != "" {
+		loc = c.VCS + "+" + loc
+	}
+	return loc
+}
+
+type spdxDoc struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func spdxDocument(components []sbomComponent, id [32]byte, created string) spdxDoc {
+	const noAssertion = "NOASSERTION"
+	names := []string{projectName()}
+	for _, c := range components {
+		names = append(names, c.Importpath)
+	}
+	refs := vendor.SPDXIDs("SPDXRef-Package-", names)
+	root := refs[0]
+	doc := spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              projectName(),
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/gvt/%x", id),
+		CreationInfo: spdxCreationInfo{
+			Created:  created,
+			Creators: []string{"Tool: gvt"},
+		},
+		Packages: []spdxPackage{{
+			Name:             projectName(),
+			SPDXID:           root,
+			DownloadLocation: noAssertion,
+			LicenseConcluded: noAssertion,
+			LicenseDeclared:  noAssertion,
+			CopyrightText:    noAssertion,
+		}},
+		Relationships: []spdxRelationship{{"SPDXRef-DOCUMENT", "DESCRIBES", root}},
+	}
+	for i, c := range components {
+		license := c.license
+		if license == "" {
+			license = noAssertion
+		}
+		ref := refs[i+1]
+		p := spdxPackage{
+			Name:             c.Importpath,
+			SPDXID:           ref,
+			VersionInfo:      c.version,
+			DownloadLocation: c.downloadLocation(),
+			LicenseConcluded: noAssertion,
+			LicenseDeclared:  license,
+			CopyrightText:    noAssertion,
+			ExternalRefs:     []spdxExternalRef{{"PACKAGE-MANAGER", "purl", c.purl()}},
+		}
+		if c.hash != "" {
+			p.Checksums = []spdxChecksum{{"SHA256", c.hash}}
+		}
+		doc.Packages = append(doc.Packages, p)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{root, "DEPENDS_ON", ref})
+	}
+	return doc
+}
+
+type cdxDoc struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     []cdxTool    `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Name string `json:"name"`
+}
+
+type cdxComponent struct {
+	Type               string        `json:"type"`
+	BOMRef             string        `json:"bom-ref"`
+	Name               string        `json:"name"`
+	Version            string        `json:"version,omitempty"`
+	PURL               string        `json:"purl,omitempty"`
+	Hashes             []cdxHash     `json:"hashes,omitempty"`
+	Licenses           []cdxLicense  `json:"licenses,omitempty"`
+	ExternalReferences []cdxExternal `json:"externalReferences,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxLicense struct {
+	Expression string `json:"expression"`
+}
+
+type cdxExternal struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func cycloneDXDocument(components []sbomComponent, id [32]byte, created string) cdxDoc {
+	root := cdxComponent{
+		Type:   "application",
+		BOMRef: projectName(),
+		Name:   projectName(),
+	}
+	doc := cdxDoc{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + uuidFrom(id),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: created,
+			Tools:     []cdxTool{{"gvt"}},
+			Component: root,
+		},
+		Components: []cdxComponent{},
+	}
+	deps := cdxDependency{Ref: root.BOMRef}
+	for _, c := range components {
+		cc := cdxComponent{
+			Type:               "library",
+			BOMRef:             c.purl(),
+			Name:               c.Importpath,
+			Version:            c.version,
+			PURL:               c.purl(),
+			ExternalReferences: []cdxExternal{{"vcs", c.Repository}},
+		}
+		if c.hash != "" {
+			cc.Hashes = []cdxHash{{"SHA-256", c.hash}}
+		}
+		if c.license != "" {
+			cc.Licenses = []cdxLicense{{c.license}}
+		}
+		doc.Components = append(doc.Components, cc)
+		doc.Dependencies = append(doc.Dependencies, cdxDependency{Ref: cc.BOMRef})
+		deps.DependsOn = append(deps.DependsOn, cc.BOMRef)
+	}
+	doc.Dependencies = append([]cdxDependency{deps}, doc.Dependencies...)
+	return doc
+}
+
+// uuidFrom formats the first bytes of a hash as a name based (version 5
+// like) UUID.
+func uuidFrom(h [32]byte) string {
+	b := h[:16]
+	b[6] = b[6]&0x0f | 0x50
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/uk702/gvt/fileutils"
+)
+
+// importRule tells whether the imports below prefix are always fetched or
+// always ignored.
+type importRule struct {
+	prefix string
+	fetch  bool
+}
+
+// importRules are read from the importRules file, one "prefix fetch" or
+// "prefix ignore" rule per line.
+var importRules []importRule
+
+// readImportRules parses the import rules file at path.
+func readImportRules(path string) ([]importRule, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []importRule
+	for i, line := range strings.Split(string(content), "\n") {
+		if j := strings.Index(line, "#"); j >= 0 {
+			line = line[:j]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || fields[1] != "fetch" && fields[1] != "ignore" {
+			return nil, fmt.Errorf("%s:%d: expected \"prefix fetch\" or \"prefix ignore\"", path, i+1)
+		}
+		rules = append(rules, importRule{
+			prefix: strings.TrimSuffix(fields[0], "/"),
+			fetch:  fields[1] == "fetch",
+		})
+	}
+	return rules, nil
+}
+
+// configFile returns the path of the named configuration file, looking in
+// the current directory first and then in the home directory, or "" if
+// there is none.
+func configFile(name string) string {
+	if fileutils.IsFileExist(name) {
+		return name
+	}
+	home := os.Getenv("HOME")
+	if runtime.GOOS == "windows" {
+		home = "C:" + os.Getenv("HOMEPATH")
+	}
+	if p := filepath.Join(home, name); fileutils.IsFileExist(p) {
+		return p
+	}
+	return ""
+}
+
+// matchImportRule returns the rule with the longest prefix matching p.
+func matchImportRule(p string) (importRule, bool) {
+	var (
+		rule  importRule
+		found bool
+	)
+	for _, r := range importRules {
+		if contains(r.prefix, p) && (!found || len(r.prefix) > len(rule.prefix)) {
+			rule, found = r, true
+		}
+	}
+	return rule, found
+}
+
+// isStdlib reports whether p is a package of the standard library, that is
+// a directory of GOROOT/src. "C" is the cgo pseudo package.
+func isStdlib(p string) bool {
+	if p == "C" {
+		return true
+	}
+	src := filepath.Join(build.Default.GOROOT, "src")
+	if build.Default.GOROOT == "" || !isDir(src) {
+		// no GOROOT to look into, standard packages have no host name
+		return !strings.Contains(strings.SplitN(p, "/", 2)[0], ".")
+	}
+	return isDir(filepath.Join(src, filepath.FromSlash(p)))
+}
+
+// ignoredImport reports whether p needs not be vendored, because it is part
+// of the standard library or matches an ignore rule.
+func ignoredImport(p string) bool {
+	if r, ok := matchImportRule(p); ok {
+		return !r.fetch
+	}
+	return isStdlib(p)
+}
+
+// shouldFetch reports whether gvt has to fetch the import p: any import
+// but those of the standard library, unless an import rule matches it.
+func shouldFetch(p string) bool {
+	return !ignoredImport(p)
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var cmdUnused = &Command{
+	Name:      "unused",
+	UsageLine: "unused [-strict]",
+	Short:     "list dependencies nothing imports",
+	Long: `unused lists the dependencies of the manifest of which no package is imported
+by the project, directly or through other vendored packages.
+
+It exits with an error if any dependency is unused, so that it can be used to
+check the vendor folder in continuous integration. gvt prune removes them.
+
+Flags:
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("unused: unexpected arguments")
+		}
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		g, err := loadImportGraph(m)
+		if err != nil {
+			return fmt.Errorf("could not parse the imports of the project: %v", err)
+		}
+
+		var unused int
+		for _, d := range m.Dependencies {
+			if !g.uses(d.Importpath) {
+				fmt.Println(d.Importpath)
+				unused++
+			}
+		}
+		if unused > 0 {
+			return fmt.Errorf("%d unused dependencies", unused)
+		}
+		return nil
+	},
+	AddFlags: addStrictFlag,
+}
@@ -1,42 +1,85 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/uk702/gvt/fileutils"
 	"github.com/uk702/gvt/gbvendor"
 )
 
 var (
-	updateAll bool // update all dependencies
+	updateAll      bool   // update all dependencies
+	updateBranch   string // branch to switch to
+	updateTag      string // tag to switch to
+	updateRevision string // revision to switch to
+	updateSemver   bool   // update only dependencies with a version constraint
+	updateDryRun   bool   // only print what would be updated
+	updateRecurse  bool   // fetch the new imports of updated dependencies
 )
 
 func addUpdateFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&updateAll, "all", false, "update all dependencies")
+	fs.StringVar(&updateBranch, "branch", "", "branch to switch to")
+	fs.StringVar(&updateTag, "tag", "", "tag to switch to")
+	fs.StringVar(&updateRevision, "revision", "", "revision to switch to")
+	fs.BoolVar(&updateSemver, "semver", false, "update dependencies with a version constraint")
+	fs.BoolVar(&updateDryRun, "dry-run", false, "print what would be updated")
+	fs.BoolVar(&updateRecurse, "recurse", false, "fetch new imports of the updated dependencies")
 	fs.BoolVar(&insecure, "precaire", false, "allow the use of insecure protocols")
+	addStrictFlag(fs)
 }
 
 var cmdUpdate = &Command{
 	Name:      "update",
-	UsageLine: "update [ -all | importpath ]",
+	UsageLine: "update [ -all | [-branch branch] [-revision rev | -tag tag] importpath ] [-semver] [-dry-run] [-recurse] [-strict]",
 	Short:     "update a local dependency",
 	Long: `update replaces the source with the latest available from the head of the fetched branch.
 
-Updating from one copy of a dependency to another is ONLY possible when the
-dependency was fetched by branch, without using -tag or -revision. It will be
-updated to the HEAD of that branch, switching branches is not supported.
+Dependencies fetched with -version are updated to the newest tag matching their
+version constraint, and dependencies fetched with -tag to the newest tag of the
+same major version, in semantic version order; use -tag to move to another
+major version. Dependencies whose tag is not a semantic version are left
+unchanged. Dependencies fetched with -revision are not updated,
+unless one of -branch, -tag or -revision is supplied.
 
-To update across branches, or from one tag/revision to another, you must first
-use delete to remove the dependency, then fetch [ -tag | -revision | -branch ]
-to replace it.
+-branch, -tag and -revision switch a dependency to another branch, tag or revision
+in place, keeping its other settings, like -t, -a, the repository and the patches.
+The version constraint is dropped.
+
+Dependencies replaced by a local directory are not updated.
 
 Flags:
 	-all
 		update all dependencies in the manifest.
+	-branch branch
+		switch to the head of the named branch. Will also be used by later updates.
+	-tag tag
+		switch to the specified tag.
+	-revision rev
+		switch to the specific revision, from the branch if one is supplied.
+	-semver
+		only update dependencies that have a version constraint.
+	-dry-run
+		print the revision each dependency would move to, the commits in between
+		and the files that would change, without changing anything. Licenses
+		refused by the license policy are reported instead of aborting.
+	-recurse
+		fetch the packages newly imported by the updated dependencies, like fetch
+		does, and report the dependencies that are not imported anymore.
 	-precaire
 		allow the use of insecure protocols.
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
 
 `,
 	Run: func(args []string) error {
@@ -45,6 +88,10 @@ Flags:
 		} else if len(args) == 1 && updateAll {
 			return fmt.Errorf("update: you cannot specify path and -all flag at once")
 		}
+		switching := updateBranch != "" || updateTag != "" || updateRevision != ""
+		if switching && (updateAll || updateSemver) {
+			return fmt.Errorf("update: -branch, -tag and -revision cannot be used with -all or -semver")
+		}
 
 		m, err := vendor.ReadManifest(manifestFile)
 		if err != nil {
@@ -64,10 +111,20 @@ Flags:
 			dependencies = append(dependencies, dependency)
 		}
 
+		dropped := make(map[string]bool) // imports removed by the updates
 		for _, d := range dependencies {
-			err = m.RemoveDependency(d)
-			if err != nil {
-				return fmt.Errorf("dependency could not be deleted from manifest: %v", err)
+			if d.Replace != "" {
+				if !updateAll {
+					return fmt.Errorf("%s is replaced by %s, use gvt replace -drop first", d.Importpath, d.Replace)
+				}
+				log.Printf("skipping %s: replaced by %s", d.Importpath, d.Replace)
+				continue
+			}
+			if updateSemver && d.Constraint == "" {
+				if !updateAll {
+					return fmt.Errorf("%s has no version constraint, fetch it with -version", d.Importpath)
+				}
+				continue
 			}
 
 			repo, err := vendor.NewRemoteRepo(d.Repository, d.VCS, insecure)
@@ -75,7 +132,33 @@ Flags:
 				return fmt.Errorf("could not determine repository for import %q", d.Importpath)
 			}
 
-			wc, err := GlobalDownloader.Get(repo, d.Branch, "", "", false)
+			b, t, r := d.Branch, "", ""
+			switch {
+			case switching:
+				b, t, r = updateBranch, updateTag, updateRevision
+			case d.Constraint != "":
+				b = ""
+				if t, err = semverTag(repo, d.Constraint); err != nil {
+					return err
+				}
+			case d.Tag != "":
+				b = ""
+				var ok bool
+				if t, ok, err = latestTag(repo, d.Tag); err != nil {
+					return err
+				}
+				if !ok {
+					log.Printf("skipping %s: its tag %s is not a semantic version, use -tag to switch it", d.Importpath, d.Tag)
+					continue
+				}
+			}
+
+			err = m.RemoveDependency(d)
+			if err != nil {
+				return fmt.Errorf("dependency could not be deleted from manifest: %v", err)
+			}
+
+			wc, err := GlobalDownloader.Get(repo, b, t, r, false)
 			if err != nil {
 				return err
 			}
@@ -90,30 +173,79 @@ Flags:
 				return err
 			}
 
-			dep := vendor.Dependency{
-				Importpath: d.Importpath,
-				Repository: repo.URL(),
-				VCS:        repo.Type(),
-				Revision:   rev,
-				Branch:     branch,
-				Path:       d.Path,
-				NoTests:    d.NoTests,
-				AllFiles:   d.AllFiles,
+			licenseFiles, err := fileutils.FindLicenseFiles(filepath.Join(wc.Dir(), d.Path), wc.Dir())
+			if err != nil {
+				return err
+			}
+			license, err := checkLicense(d.Importpath, licenseFiles)
+			licenseErr, refused := err.(*licenseError)
+			if err != nil && !(refused && updateDryRun) {
+				return err
 			}
 
-			if err := fileutils.RemoveAll(filepath.Join(vendorDir, filepath.FromSlash(d.Importpath))); err != nil {
-				// TODO(dfc) need to apply vendor.cleanpath here to remove intermediate directories.
-				return fmt.Errorf("dependency could not be deleted: %v", err)
+			dep := d
+			dep.License = license
+			dep.Repository = repo.URL()
+			dep.VCS = repo.Type()
+			dep.Revision = rev
+			dep.Branch = branch
+			dep.Tag = t
+			if updateBranch != "" {
+				dep.Branch = updateBranch
+			}
+			if switching {
+				dep.Constraint = ""
+			}
+			if dep.Revision != d.Revision && dep.Module != "" {
+				// the module version is only known from a semantic version tag
+				dep.ModuleVersion = ""
+				if _, err := vendor.ParseVersion(t); err == nil {
+					dep.ModuleVersion = t
+				}
 			}
 
-			dst := filepath.Join(vendorDir, filepath.FromSlash(dep.Importpath))
-			src := filepath.Join(wc.Dir(), dep.Path)
+			if updateDryRun {
+				if err := printUpdatePlan(d, dep, repo, wc); err != nil {
+					return err
+				}
+				if refused {
+					fmt.Printf("  license: %v\n", licenseErr)
+				}
+				continue
+			}
 
-			if err := fileutils.Copypath(dst, src, !d.NoTests, d.AllFiles); err != nil {
+			if updateRecurse {
+				imports, err := dependencyImports(d, m)
+				if err != nil {
+					return err
+				}
+				for p := range imports {
+					if contains(d.Importpath, p) {
+						// the dependency imports itself
+						continue
+					}
+					dropped[p] = true
+				}
+			}
+
+			// copy and patch the new revision aside, so that the vendor
+			// folder is left untouched if a patch does not apply anymore
+			src := filepath.Join(wc.Dir(), dep.Path)
+			stage, err := stageUpdate(dep, src, wc.Dir())
+			if err != nil {
 				return err
 			}
 
-			if err := fileutils.CopyLicense(dst, wc.Dir()); err != nil {
+			if err := fileutils.RemoveAll(filepath.Join(vendorDir, filepath.FromSlash(d.Importpath))); err != nil {
+				// TODO(dfc) need to apply vendor.cleanpath here to remove intermediate directories.
+				fileutils.RemoveAll(stage)
+				return fmt.Errorf("dependency could not be deleted: %v", err)
+			}
+
+			dst := filepath.Join(vendorDir, filepath.FromSlash(dep.Importpath))
+			err = moveDir(dst, stage)
+			fileutils.RemoveAll(stage)
+			if err != nil {
 				return err
 			}
 
@@ -124,9 +256,199 @@ Flags:
 			if err := vendor.WriteManifest(manifestFile, m); err != nil {
 				return err
 			}
+
+			if updateRecurse {
+				rootRepoURL, fetchRoot = repo.URL(), dep.Importpath
+				if err := fetchImports(m, dep, src, wc.Dir(), 0); err != nil {
+					return err
+				}
+				imports, err := dependencyImports(dep, m)
+				if err != nil {
+					return err
+				}
+				for p := range imports {
+					delete(dropped, p)
+				}
+			}
 		}
 
+		if updateRecurse {
+			return reportUnused(m, dropped)
+		}
 		return nil
 	},
 	AddFlags: addUpdateFlags,
 }
+
+// stageUpdate copies the package src of the working copy root to a new
+// temporary directory, selecting files the same way fetch does, and applies
+// the patches of dep to it.
+func stageUpdate(dep vendor.Dependency, src, root string) (string, error) {
+	dir, err := ioutil.TempDir("", "gvt-")
+	if err != nil {
+		return "", err
+	}
+	if err := fileutils.Copypath(dir, src, !dep.NoTests, dep.AllFiles); err != nil {
+		fileutils.RemoveAll(dir)
+		return "", err
+	}
+	if err := fileutils.CopyLicense(dir, src, root); err != nil {
+		fileutils.RemoveAll(dir)
+		return "", err
+	}
+	if err := applyPatches(dir, vendorDir, dep); err != nil {
+		fileutils.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// moveDir moves the directory src to dst, copying it when they are on
+// different file systems.
+func moveDir(dst, src string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	return fileutils.Copypath(dst, src, true, true)
+}
+
+// latestTag returns the newest release tag of repo of the same major version
+// as current, in semantic version order, if it is newer than current.
+// Otherwise it returns current. It returns false if current is not a
+// semantic version.
+func latestTag(repo vendor.RemoteRepo, current string) (string, bool, error) {
+	tags, err := repo.Tags()
+	if err != nil {
+		return "", false, fmt.Errorf("could not list the tags of %s: %v", repo.URL(), err)
+	}
+	tag, ok := vendor.NewerTag(tags, current)
+	return tag, ok, nil
+}
+
+// semverTag returns the newest tag of repo matching the version constraint.
+func semverTag(repo vendor.RemoteRepo, constraint string) (string, error) {
+	c, err := vendor.ParseConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		return "", fmt.Errorf("could not list the tags of %s: %v", repo.URL(), err)
+	}
+	tag, ok := vendor.LatestVersion(tags, c.Match)
+	if !ok {
+		return "", fmt.Errorf("no tag of %s matches %q", repo.URL(), constraint)
+	}
+	return tag, nil
+}
+
+// printUpdatePlan prints the revision old would be updated to, the commits in
+// between and the files that would change in the vendor folder.
+func printUpdatePlan(old, dep vendor.Dependency, repo vendor.RemoteRepo, wc vendor.WorkingCopy) error {
+	if old.Revision == dep.Revision {
+		fmt.Printf("%s: up to date at %s\n", dep.Importpath, shortRev(dep.Revision))
+		return nil
+	}
+	to := shortRev(dep.Revision)
+	if dep.Tag != "" {
+		to = dep.Tag + " (" + to + ")"
+	}
+	fmt.Printf("%s: %s -> %s\n", dep.Importpath, shortRev(old.Revision), to)
+
+	// the working copy of the new revision might be a shallow clone
+	full, err := GlobalDownloader.Get(repo, "", "", old.Revision, false)
+	if err != nil {
+		return err
+	}
+	if history, ok := full.(vendor.History); !ok {
+		fmt.Printf("  commits: history of %s repositories is not supported\n", repo.Type())
+	} else if commits, err := history.Log(old.Revision, dep.Revision); err != nil {
+		fmt.Printf("  commits: %v\n", err)
+	} else {
+		fmt.Printf("  commits:\n")
+		for _, c := range commits {
+			fmt.Printf("    %s %s\n", shortRev(c.Revision), c.Subject)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "gvt-")
+	if err != nil {
+		return err
+	}
+	defer fileutils.RemoveAll(dir)
+	src := filepath.Join(wc.Dir(), dep.Path)
+	if err := fileutils.Copypath(dir, src, !dep.NoTests, dep.AllFiles); err != nil {
+		return err
+	}
+	if err := fileutils.CopyLicense(dir, src, wc.Dir()); err != nil {
+		return err
+	}
+	if err := applyPatches(dir, vendorDir, dep); err != nil {
+		fmt.Printf("  %v\n", err)
+	}
+
+	var buf bytes.Buffer
+	local := filepath.Join(vendorDir, filepath.FromSlash(dep.Importpath))
+	if _, err := diffDirs(&buf, local, dir, path.Join("vendor", dep.Importpath)+"/", "--name-status"); err != nil {
+		return err
+	}
+	fmt.Printf("  files:\n")
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line != "" {
+			fmt.Printf("    %s\n", strings.Replace(line, "\t", " ", -1))
+		}
+	}
+	return nil
+}
+
+// dependencyImports returns the imports of the vendored copy of dep.
+func dependencyImports(dep vendor.Dependency, m *vendor.Manifest) (map[string]bool, error) {
+	buildTags, err := manifestBuildTags(m)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(vendorDir, filepath.FromSlash(dep.Importpath))
+	imports, err := vendor.ParseImports(dir, dir, dep.Importpath, !dep.NoTests, dep.AllFiles, buildTags)
+	if err = tolerateParseErrors(err); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to parse imports: %s", err)
+	}
+	return imports, nil
+}
+
+// reportUnused logs the dependencies providing one of the packages in dropped
+// that the project does not import anymore, directly or through the other
+// dependencies it imports.
+func reportUnused(m *vendor.Manifest, dropped map[string]bool) error {
+	if len(dropped) == 0 {
+		return nil
+	}
+	// only the packages reachable from the project keep a dependency
+	g, err := loadImportGraph(m)
+	if err != nil {
+		return fmt.Errorf("failed to parse imports: %s", err)
+	}
+
+	unused := make(map[string]bool)
+	for p := range dropped {
+		d, err := m.GetDependencyForImportpath(p)
+		if err != nil || unused[d.Importpath] {
+			continue
+		}
+		if !g.uses(d.Importpath) {
+			unused[d.Importpath] = true
+		}
+	}
+
+	var paths []string
+	for p := range unused {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		log.Printf("%s is not imported anymore, it can be removed with gvt delete", p)
+	}
+	return nil
+}
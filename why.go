@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/uk702/gvt/gbvendor"
+)
+
+var cmdWhy = &Command{
+	Name:      "why",
+	UsageLine: "why [-strict] importpath",
+	Short:     "explain why a package is vendored",
+	Long: `why prints the shortest chains of imports from the packages of the project to
+the vendored packages at or below the import path.
+
+A chain is printed for each such package the project uses, starting with a
+project package and ending with the vendored package. If the project does not
+use any of them, why reports that they are not needed.
+
+Flags:
+	-strict
+		fail on Go files which cannot be parsed, instead of ignoring their
+		imports and listing them at the end.
+
+`,
+	Run: func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("why: import path missing")
+		}
+		target := args[0]
+
+		m, err := vendor.ReadManifest(manifestFile)
+		if err != nil {
+			return fmt.Errorf("could not load manifest: %v", err)
+		}
+
+		g, err := loadImportGraph(m)
+		if err != nil {
+			return fmt.Errorf("could not parse the imports of the project: %v", err)
+		}
+
+		var targets []string
+		for p := range g.vendored {
+			if contains(target, p) {
+				targets = append(targets, p)
+			}
+		}
+		if len(targets) == 0 {
+			fmt.Printf("%s is not needed by the project\n", target)
+			return nil
+		}
+		sort.Strings(targets)
+
+		prev := g.shortestPaths()
+		for i, t := range targets {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("# %s\n", t)
+			var chain []string
+			for p := t; p != ""; p = prev[p] {
+				chain = append(chain, p)
+			}
+			for i := len(chain) - 1; i >= 0; i-- {
+				fmt.Println(chain[i])
+			}
+		}
+		return nil
+	},
+	AddFlags: addStrictFlag,
+}
+
+// shortestPaths walks the graph breadth first from the project packages. It
+// returns, for each package reached, the previous package on a shortest chain
+// of imports from the project. Project packages map to "".
+func (g *importGraph) shortestPaths() map[string]string {
+	prev := make(map[string]string)
+	queue := sortedKeys(g.project)
+	for _, p := range queue {
+		prev[p] = ""
+	}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range g.imports[p] {
+			if _, ok := prev[d]; ok || !g.vendored[d] {
+				continue
+			}
+			prev[d] = p
+			queue = append(queue, d)
+		}
+	}
+	return prev
+}